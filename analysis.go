@@ -0,0 +1,140 @@
+package gobot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HintCount is how many moves the hint command suggests
+const HintCount = 3
+
+// markerLabels label marked points on the board, in order
+const markerLabels = "ABCDE"
+
+// Candidate is a move suggested by an analyzer
+type Candidate struct {
+	Move *Move
+	// WinRate is the chance the move wins for the player making it, or -1 if
+	// the analyzer cannot tell
+	WinRate float64
+}
+
+// Analyzer suggests moves in a position
+type Analyzer interface {
+	// Analyze returns up to n moves for a color given the boards played so
+	// far, best first
+	Analyze(history History, next Stone, n int) ([]Candidate, error)
+}
+
+// Marker labels a point on the board image
+type Marker struct {
+	Coords Coords
+	Label  byte
+}
+
+// Analyze implements the Analyzer interface with the moves the search tried
+// most
+func (e *MCTS) Analyze(
+	history History, next Stone, n int,
+) ([]Candidate, error) {
+	root := e.search(history, next)
+	children := append([]*mctsNode{}, root.children...)
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].visits > children[j].visits
+	})
+	candidates := []Candidate{}
+	for _, child := range children {
+		if len(candidates) == n || child.visits == 0 {
+			break
+		}
+		candidates = append(candidates, Candidate{
+			Move:    child.move,
+			WinRate: child.wins / float64(child.visits),
+		})
+	}
+	return candidates, nil
+}
+
+// Analyze implements the Analyzer interface. GTP engines only name their
+// best move, without a win rate, so hints from them have one move however
+// many are asked for.
+func (c *GTPClient) Analyze(
+	history History, next Stone, n int,
+) ([]Candidate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.sync(history)
+	if err != nil {
+		return nil, err
+	}
+	vertex, err := c.command("reg_genmove", gtpColor(next))
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseGTPVertex(vertex)
+	if err != nil {
+		return nil, err
+	}
+	return []Candidate{{Move: m, WinRate: -1}}, nil
+}
+
+// hint suggests moves for the player to move and marks them on the board.
+// Analysis is slow, so it is done after replying, without the session lock.
+// The replies go only to the private player, if one is given. The caller
+// must hold the session lock.
+func hint(s *Session, a Analyzer, private string) (*Response, error) {
+	if a == nil {
+		return nil, errors.New("no analysis is set up")
+	}
+	history, next := s.Game.Position()
+	history = append(History{}, history...)
+	hinted := NewSessionResponse(s, "")
+	hinted.Private = private
+	response := NewTextResponse(fmt.Sprintf(
+		"looking for moves in game %d", hinted.ID,
+	))
+	response.Private = private
+	response.Later = func(ctx context.Context) []*Response {
+		candidates, err := a.Analyze(history, next, HintCount)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			failed := NewTextResponse(fmt.Sprintf(
+				"could not find moves in game %d: %s", hinted.ID, err.Error(),
+			))
+			failed.Channel, failed.Private = hinted.Channel, private
+			return []*Response{failed}
+		}
+		hinted.Details, hinted.Markers = hints(candidates, next)
+		return []*Response{hinted}
+	}
+	return response, nil
+}
+
+// hints describes the suggested moves and labels them on the board
+func hints(candidates []Candidate, next Stone) (string, []Marker) {
+	markers := []Marker{}
+	parts := []string{}
+	for _, c := range candidates {
+		part := "pass"
+		if !c.Move.Pass {
+			label := markerLabels[len(markers)]
+			part = fmt.Sprintf("%c %s", label, c.Move.Coords.String())
+			markers = append(markers, Marker{Coords: c.Move.Coords, Label: label})
+		}
+		if c.WinRate >= 0 {
+			part += fmt.Sprintf(" (%.0f%%)", 100*c.WinRate)
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return "no moves to suggest", markers
+	}
+	return fmt.Sprintf(
+		"hints for %s: %s", colorName(next), strings.Join(parts, ", "),
+	), markers
+}
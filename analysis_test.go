@@ -0,0 +1,112 @@
+package gobot_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestMCTSAnalyze(t *testing.T) {
+	e := NewMCTS(NewRNG(1))
+	e.Playouts = 1000
+	e.Komi = 0
+	e.Clock = NewFakeClock(time.Now())
+	history := History{compactBoard(t, ".../.../...")}
+	candidates, err := e.Analyze(history, BlackStone, 3)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(candidates) != 3 {
+		t.Fatalf("expected 3 candidates but got %v", candidates)
+	}
+	if *candidates[0].Move != (Move{Coords: Coords{1, 1}}) {
+		t.Errorf("expected the center first but got %v", candidates[0].Move)
+	}
+	for _, c := range candidates {
+		if c.WinRate < 0 || c.WinRate > 1 {
+			t.Errorf("expected a win rate for %v but got %f", c.Move, c.WinRate)
+		}
+	}
+}
+
+func TestGTPAnalyze(t *testing.T) {
+	c := startStubEngine(t)
+	defer c.Close()
+	history := History{New19by19Board()}
+	candidates, err := c.Analyze(history, BlackStone, 3)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []Candidate{{Move: &Move{Coords: Coords{0, 0}}, WinRate: -1}}
+	if !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("expected %v but got %v", expected, candidates)
+	}
+	// the suggestion was not played
+	m, err := c.GenMove(history, BlackStone)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if *m != (Move{Coords: Coords{0, 0}}) {
+		t.Errorf("expected the engine to play A1 but got %v", m)
+	}
+}
+
+// fakeAnalyzer suggests the given moves
+type fakeAnalyzer struct {
+	candidates []Candidate
+}
+
+func (a *fakeAnalyzer) Analyze(
+	history History, next Stone, n int,
+) ([]Candidate, error) {
+	return a.candidates, nil
+}
+
+func TestServerHint(t *testing.T) {
+	server := newTestServer(t, "start U1 U2")
+	defer server.Close()
+	if err := server.Handle("hint", "U1", "C1"); err == nil {
+		t.Errorf("expected a hint without analysis to fail")
+	}
+
+	server.Analyzer = &fakeAnalyzer{candidates: []Candidate{
+		{Move: &Move{Coords: Coords{3, 3}}, WinRate: 0.62},
+		{Move: &Move{Pass: true}, WinRate: 0.5},
+		{Move: &Move{Coords: Coords{15, 15}}, WinRate: -1},
+	}}
+	cases := []struct {
+		input   string
+		private string
+	}{
+		{"hint 1", ""},
+		{"hint private", "U2"},
+	}
+	for _, c := range cases {
+		if err := server.Handle(c.input, "U2", "C1"); err != nil {
+			t.Fatalf("%s: %s", c.input, err.Error())
+		}
+		if r := <-server.Replies; r.Private != c.private {
+			t.Errorf("%s: expected the reply to go to %q but got %q", c.input,
+				c.private, r.Private)
+		}
+		r := <-server.Replies
+		details := "hints for black: A D4 (62%), pass (50%), B P16"
+		if r.Details != details {
+			t.Errorf("%s: expected %q but got %q", c.input, details, r.Details)
+		}
+		markers := []Marker{
+			{Coords: Coords{3, 3}, Label: 'A'},
+			{Coords: Coords{15, 15}, Label: 'B'},
+		}
+		if !reflect.DeepEqual(r.Markers, markers) {
+			t.Errorf("%s: expected markers %v but got %v", c.input, markers,
+				r.Markers)
+		}
+		if r.Private != c.private {
+			t.Errorf("%s: expected it to be sent to %q but got %q", c.input,
+				c.private, r.Private)
+		}
+	}
+}
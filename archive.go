@@ -0,0 +1,165 @@
+package gobot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// ArchiveGames is the name of the file in an archive that holds the games,
+// one JSON encoded ArchivedGame per line
+const ArchiveGames = "games.jsonl"
+
+// ArchivedGame is a game as it is kept in an archive
+type ArchivedGame struct {
+	ID      int64           `json:"id"`
+	Version int64           `json:"version"`
+	Game    json.RawMessage `json:"game"`
+	Events  []Event         `json:"events"`
+}
+
+// Export writes every game in a store, archived or not, to w as a gzipped
+// tar archive. The archive holds games.jsonl, which Import reads back, and an
+// SGF of each game under sgf/ for other go programs, naming players with a
+// directory. Returns how many games were written.
+func Export(store Store, d Directory, w io.Writer) (int, error) {
+	sessions, err := store.List(true)
+	if err != nil {
+		return 0, err
+	}
+	archived, err := store.ListArchived()
+	if err != nil {
+		return 0, err
+	}
+	sessions = append(sessions, archived...)
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Storable.ID() < sessions[j].Storable.ID()
+	})
+	lines := &bytes.Buffer{}
+	encoder := json.NewEncoder(lines)
+	sgfs := [][]byte{}
+	for _, sess := range sessions {
+		id := sess.Storable.ID()
+		blob, err := json.Marshal(sess.Storable)
+		if err != nil {
+			return 0, fmt.Errorf("game %d: %s", id, err)
+		}
+		events, err := store.Events(id, 0)
+		if err != nil {
+			return 0, fmt.Errorf("game %d: %s", id, err)
+		}
+		err = encoder.Encode(ArchivedGame{
+			ID:      id,
+			Version: sess.Storable.Version(),
+			Game:    blob,
+			Events:  events,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("game %d: %s", id, err)
+		}
+		game := &State{}
+		err = json.Unmarshal(blob, game)
+		if err != nil {
+			return 0, fmt.Errorf("game %d: %s", id, err)
+		}
+		game.id = id
+		sgfs = append(sgfs, []byte(game.SGF(d)))
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	err = writeArchiveFile(tw, ArchiveGames, lines.Bytes(), now)
+	if err != nil {
+		return 0, err
+	}
+	for i, sess := range sessions {
+		name := fmt.Sprintf("sgf/%d.sgf", sess.Storable.ID())
+		err = writeArchiveFile(tw, name, sgfs[i], now)
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = tw.Close()
+	if err != nil {
+		return 0, err
+	}
+	return len(sessions), gz.Close()
+}
+
+// Import restores every game in an archive written by Export into a store.
+// Games keep their ids, replacing any game in the store with the same id.
+// Returns how many games were restored.
+func Import(store Store, r io.Reader) (int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return 0, fmt.Errorf("archive has no %s", ArchiveGames)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if header.Name == ArchiveGames {
+			break
+		}
+	}
+	count := 0
+	decoder := json.NewDecoder(tr)
+	for {
+		game := ArchivedGame{}
+		err = decoder.Decode(&game)
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("reading game %d: %s", count+1, err)
+		}
+		if game.ID <= 0 {
+			return count, errors.New("archived game has no id")
+		}
+		err = store.Restore(game)
+		if err != nil {
+			return count, fmt.Errorf("game %d: %s", game.ID, err)
+		}
+		count++
+	}
+}
+
+// restoredEvents returns the events of an archived game, belonging to the
+// game's id
+func restoredEvents(a ArchivedGame) []Event {
+	events := make([]Event, len(a.Events))
+	for i, e := range a.Events {
+		e.Game = a.ID
+		events[i] = e
+	}
+	return events
+}
+
+func writeArchiveFile(
+	tw *tar.Writer, name string, contents []byte, modified time.Time,
+) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(contents)),
+		ModTime: modified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(contents)
+	return err
+}
@@ -0,0 +1,163 @@
+package gobot_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestExportImport(t *testing.T) {
+	clock := NewFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	source := NewMemoryStore("")
+	source.Use(clock, NewRNG(1))
+	for i := 0; i < 3; i++ {
+		sess, err := source.New(Blueprint{
+			Players: Players{Black: []string{"U1"}, White: []string{"U2"}},
+			Channel: "C1",
+			Creator: "U1",
+		})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		clock.Advance(time.Hour)
+		move := &Move{Coords: Coords{3, 3}}
+		sess.Game.Move(move)
+		sess.Storable.Record(NewMoveEvent("U1", move))
+		if i == 1 {
+			// finish the second game
+			for _, player := range []string{"U2", "U1"} {
+				pass := &Move{Pass: true}
+				sess.Game.Move(pass)
+				sess.Storable.Record(NewMoveEvent(player, pass))
+			}
+		}
+		err = source.Save(sess.Storable)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	archive := &bytes.Buffer{}
+	count, err := Export(source, UserIDs{}, archive)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if count != 3 {
+		t.Errorf("expected 3 games exported but got %d", count)
+	}
+	files := archiveFiles(t, archive.Bytes())
+	for _, name := range []string{"games.jsonl", "sgf/1.sgf", "sgf/3.sgf"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected %s in the archive", name)
+		}
+	}
+
+	// restore into a store that already has a game in the way
+	target := NewMemoryStore("")
+	target.New(Blueprint{})
+	count, err = Import(target, bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if count != 3 {
+		t.Errorf("expected 3 games imported but got %d", count)
+	}
+	for id := int64(1); id <= 3; id++ {
+		before, _ := source.Get(id)
+		after, err := target.Get(id)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		b, a := before.Game.(*State), after.Game.(*State)
+		if !a.UpdatedAt.Equal(b.UpdatedAt) || !a.CreatedAt.Equal(b.CreatedAt) ||
+			a.Finished() != b.Finished() ||
+			after.Storable.Version() != before.Storable.Version() ||
+			!reflect.DeepEqual(a.History, b.History) {
+			t.Errorf("game %d changed on import", id)
+		}
+		beforeEvents, _ := source.Events(id, 0)
+		afterEvents, _ := target.Events(id, 0)
+		if len(afterEvents) != len(beforeEvents) {
+			t.Errorf(
+				"game %d: expected %d events but got %d",
+				id, len(beforeEvents), len(afterEvents),
+			)
+		}
+	}
+	unfinished, _ := target.List(false)
+	if len(unfinished) != 2 {
+		t.Errorf("expected 2 unfinished games but got %d", len(unfinished))
+	}
+	// new games are numbered after the restored ones
+	sess, err := target.New(Blueprint{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if sess.Storable.ID() != 4 {
+		t.Errorf("expected game 4 but got %d", sess.Storable.ID())
+	}
+}
+
+func TestImportBadArchive(t *testing.T) {
+	cases := []struct {
+		desc    string
+		archive []byte
+	}{
+		{"not gzip", []byte("hello")},
+		{"no games", makeArchive(t, map[string]string{"sgf/1.sgf": "(;)"})},
+		{"bad json", makeArchive(t, map[string]string{"games.jsonl": "{"})},
+		{"no id", makeArchive(t, map[string]string{"games.jsonl": "{}\n"})},
+	}
+	for _, test := range cases {
+		_, err := Import(NewMemoryStore(""), bytes.NewReader(test.archive))
+		if err == nil {
+			t.Errorf("%s: expected an error", test.desc)
+		}
+	}
+}
+
+func archiveFiles(t *testing.T, archive []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		contents, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		files[header.Name] = string(contents)
+	}
+}
+
+func makeArchive(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, contents := range files {
+		tw.WriteHeader(&tar.Header{
+			Name: name, Mode: 0644, Size: int64(len(contents)),
+		})
+		io.Copy(tw, strings.NewReader(contents))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
@@ -1,7 +1,10 @@
 package gobot
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 )
 
 const (
@@ -18,6 +21,17 @@ const (
 // Stone is the color of stone on the board
 type Stone int8
 
+// Opponent returns the color of the other player
+func (s Stone) Opponent() Stone {
+	switch s {
+	case BlackStone:
+		return WhiteStone
+	case WhiteStone:
+		return BlackStone
+	}
+	return s
+}
+
 // Board is the state of the current game
 type Board [][]Stone
 
@@ -34,10 +48,15 @@ func (h History) Ko(b Board) bool {
 
 // New19by19Board creates an empty 19x19 board
 func New19by19Board() Board {
+	return NewBoard(19)
+}
+
+// NewBoard creates an empty board of the given size
+func NewBoard(size int) Board {
 	stones := [][]Stone{}
-	for i := 0; i < 19; i++ {
+	for i := 0; i < size; i++ {
 		stones = append(stones, []Stone{})
-		for j := 0; j < 19; j++ {
+		for j := 0; j < size; j++ {
 			stones[i] = append(stones[i], EmptyStone)
 		}
 	}
@@ -211,6 +230,67 @@ func (b Board) Play(x, y int, stone Stone) (Board, int, error) {
 	return copy, captures, nil
 }
 
+// stoneLetters are the letters used to encode stones in the compact format
+var stoneLetters = map[Stone]byte{
+	EmptyStone: '.',
+	BlackStone: 'B',
+	WhiteStone: 'W',
+}
+
+// MarshalJSON encodes the board compactly as a string of rows separated by
+// slashes, using . for empty points and B and W for stones.
+func (b Board) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return []byte("null"), nil
+	}
+	rows := make([]string, len(b))
+	for i, row := range b {
+		letters := make([]byte, len(row))
+		for j, stone := range row {
+			letter, ok := stoneLetters[stone]
+			if !ok {
+				return nil, fmt.Errorf("cannot encode stone %d", stone)
+			}
+			letters[j] = letter
+		}
+		rows[i] = string(letters)
+	}
+	return json.Marshal(strings.Join(rows, "/"))
+}
+
+// UnmarshalJSON decodes a board in the compact format, or in the legacy
+// format of nested arrays of stones.
+func (b *Board) UnmarshalJSON(data []byte) error {
+	var legacy [][]Stone
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		*b = Board(legacy)
+		return nil
+	}
+	var compact string
+	if err := json.Unmarshal(data, &compact); err != nil {
+		return err
+	}
+	rows := strings.Split(compact, "/")
+	board := make(Board, len(rows))
+	for i, row := range rows {
+		board[i] = make([]Stone, len(row))
+		for j := range row {
+			switch row[j] {
+			case '.':
+				board[i][j] = EmptyStone
+			case 'B':
+				board[i][j] = BlackStone
+			case 'W':
+				board[i][j] = WhiteStone
+			default:
+				return fmt.Errorf("unknown stone %q", row[j])
+			}
+		}
+	}
+	*b = board
+	return nil
+}
+
 // Equals checks if two board states are equivalent
 func (b Board) Equals(o Board) bool {
 	if len(b) != len(o) {
@@ -1,6 +1,7 @@
 package gobot_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	. "github.com/crestonbunch/gobot"
@@ -403,3 +404,58 @@ func TestBoardPlay(t *testing.T) {
 		}
 	}
 }
+
+func TestBoardJSON(t *testing.T) {
+	cases := []struct {
+		input  string
+		expect Board
+		err    bool
+	}{
+		{
+			input: `".B/W."`,
+			expect: Board([][]Stone{
+				{EmptyStone, BlackStone},
+				{WhiteStone, EmptyStone},
+			}),
+		}, {
+			input: `[[0,1],[2,0]]`,
+			expect: Board([][]Stone{
+				{EmptyStone, BlackStone},
+				{WhiteStone, EmptyStone},
+			}),
+		}, {
+			input:  `null`,
+			expect: nil,
+		}, {
+			input: `".B/X."`,
+			err:   true,
+		},
+	}
+	for _, test := range cases {
+		var board Board
+		err := json.Unmarshal([]byte(test.input), &board)
+		if err != nil && !test.err {
+			t.Errorf("unexpected error %s for %s", err.Error(), test.input)
+		}
+		if err == nil && test.err {
+			t.Errorf("expected error for %s", test.input)
+		}
+		if !test.err && !board.Equals(test.expect) {
+			t.Errorf("expected %v but got %v", test.expect, board)
+		}
+	}
+
+	board := New19by19Board().Set(3, 3, BlackStone).Set(15, 15, WhiteStone)
+	blob, err := json.Marshal(board)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	var decoded Board
+	err = json.Unmarshal(blob, &decoded)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !decoded.Equals(board) {
+		t.Errorf("expected %v but got %v", board, decoded)
+	}
+}
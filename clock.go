@@ -0,0 +1,88 @@
+package gobot
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers. Games use a Clock instead of the
+// time package so that vote timers can be driven deterministically.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTimer creates a timer that fires after the given duration
+	NewTimer(time.Duration) Timer
+}
+
+// Timer fires once after a duration, like a time.Timer
+type Timer interface {
+	// Chan returns the channel the timer fires on
+	Chan() <-chan time.Time
+	// Reset changes the timer to fire after the given duration
+	Reset(time.Duration) bool
+	// Stop prevents the timer from firing
+	Stop() bool
+}
+
+// RNG is a source of random numbers used to pick votes
+type RNG interface {
+	// Intn returns a random number in [0, n)
+	Intn(n int) int
+}
+
+// SystemClock is a Clock backed by the time package
+type SystemClock struct{}
+
+// Now implements the Clock interface
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer implements the Clock interface
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) Chan() <-chan time.Time {
+	return t.C
+}
+
+// Seeder is an RNG that can make other RNGs from its seed, so that each game
+// draws from its own sequence however games take turns drawing
+type Seeder interface {
+	// Derive creates an RNG seeded from this one's seed and some keys
+	Derive(keys ...int64) RNG
+}
+
+// NewRNG creates a random number generator from a seed. The same seed always
+// produces the same sequence of votes. It is safe for concurrent use.
+func NewRNG(seed int64) RNG {
+	return &lockedRNG{seed: seed, r: rand.New(rand.NewSource(seed))}
+}
+
+type lockedRNG struct {
+	mu   sync.Mutex
+	seed int64
+	r    *rand.Rand
+}
+
+// Derive implements the Seeder interface
+func (l *lockedRNG) Derive(keys ...int64) RNG {
+	seed := l.seed
+	for _, k := range keys {
+		// mix each key in like a linear congruential step
+		seed = seed*6364136223846793005 + k + 1442695040888963407
+	}
+	return NewRNG(seed)
+}
+
+func (l *lockedRNG) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
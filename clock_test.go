@@ -0,0 +1,168 @@
+package gobot_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+// FakeClock is a Clock that only moves when it is advanced. Timers created
+// by it fire when the clock is advanced past their deadline.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock creates a fake clock starting at the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now implements the Clock interface
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer implements the Clock interface
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	t.schedule(d)
+	return t
+}
+
+// Advance moves the clock forward and fires any timers that are due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.deadline.After(c.now) {
+			t.fire()
+		}
+	}
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) Chan() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.schedule(d)
+	return active
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.active = false
+	t.drain()
+	return active
+}
+
+// schedule must be called with the clock locked
+func (t *fakeTimer) schedule(d time.Duration) {
+	t.drain()
+	t.deadline = t.clock.now.Add(d)
+	t.active = true
+	if d <= 0 {
+		t.fire()
+	}
+}
+
+func (t *fakeTimer) fire() {
+	t.active = false
+	select {
+	case t.c <- t.clock.now:
+	default:
+	}
+}
+
+// drain a stale tick so a stopped or reset timer never fires early, like
+// time.Timer since Go 1.23
+func (t *fakeTimer) drain() {
+	select {
+	case <-t.c:
+	default:
+	}
+}
+
+func TestFakeClockAdvance(t *testing.T) {
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	short := clock.NewTimer(time.Minute)
+	long := clock.NewTimer(time.Hour)
+	stopped := clock.NewTimer(time.Minute)
+	if !stopped.Stop() {
+		t.Errorf("expected active timer to stop")
+	}
+
+	clock.Advance(time.Minute)
+	if !clock.Now().Equal(start.Add(time.Minute)) {
+		t.Errorf("expected clock at %s but was %s", start, clock.Now())
+	}
+	select {
+	case fired := <-short.Chan():
+		if !fired.Equal(start.Add(time.Minute)) {
+			t.Errorf("timer fired at %s", fired)
+		}
+	default:
+		t.Errorf("expected short timer to fire")
+	}
+	select {
+	case <-long.Chan():
+		t.Errorf("long timer fired early")
+	case <-stopped.Chan():
+		t.Errorf("stopped timer fired")
+	default:
+	}
+
+	clock.Advance(time.Hour)
+	select {
+	case <-long.Chan():
+	default:
+		t.Errorf("expected long timer to fire")
+	}
+	select {
+	case <-short.Chan():
+		t.Errorf("short timer fired twice")
+	default:
+	}
+}
+
+func TestFakeClockReset(t *testing.T) {
+	clock := NewFakeClock(time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC))
+	timer := clock.NewTimer(0)
+	select {
+	case <-timer.Chan():
+	default:
+		t.Errorf("expected zero timer to fire immediately")
+	}
+	if timer.Reset(time.Minute) {
+		t.Errorf("expected fired timer to be inactive")
+	}
+	clock.Advance(time.Minute)
+	select {
+	case <-timer.Chan():
+	default:
+		t.Errorf("expected reset timer to fire")
+	}
+}
@@ -1,8 +1,11 @@
 package gobot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // Command issues a command and returns a response
@@ -12,14 +15,25 @@ type Command interface {
 
 // StartCommand is a command to start a new game.
 type StartCommand struct {
-	Anyone bool
-	White  []string
-	Black  []string
+	Anyone   bool
+	White    []string
+	Black    []string
+	Duration time.Duration
 }
 
-// Execute a start command to begin a new game
+// Execute a start command to begin a new game. The engine moves first if it
+// plays black.
 func (c *StartCommand) Execute(r *Request) (*Response, error) {
-	return InitializerPipeline.Run(r.Session, r.Player, nil)
+	response, err := InitializerPipeline.Run(r.Session, r.Player, nil)
+	if err != nil {
+		return nil, err
+	}
+	return withEngineMove(r, response)
+}
+
+// Engine checks if the engine is one of the players
+func (c *StartCommand) Engine() bool {
+	return contains(c.Black, EngineID) || contains(c.White, EngineID)
 }
 
 // MoveCommand is a command to make a move.
@@ -28,9 +42,24 @@ type MoveCommand struct {
 	Locator Locator
 }
 
-// Execute a move command to make a move
+// Execute a move command to make a move. The engine answers right away if it
+// is playing.
 func (c *MoveCommand) Execute(r *Request) (*Response, error) {
-	return MovePipeline.Run(r.Session, r.Player, c.Move)
+	response, err := MovePipeline.Run(r.Session, r.Player, c.Move)
+	if err != nil {
+		return nil, err
+	}
+	return withEngineMove(r, response)
+}
+
+// ResignCommand is a command to resign a two player game on your turn
+type ResignCommand struct {
+	Locator Locator
+}
+
+// Execute a resign command to give up the game
+func (c *ResignCommand) Execute(r *Request) (*Response, error) {
+	return ResignPipeline.Run(r.Session, r.Player, nil)
 }
 
 // VoteCommand is a command to vote for a move
@@ -46,9 +75,25 @@ type PlayCommand struct {
 	Locator Locator
 }
 
-// Execute a play command to make a move
+// Execute a play command to make a move. In a two player game it asks the
+// engine to move, e.g. if it failed to before. Says so if there are no votes
+// to pick.
 func (c *PlayCommand) Execute(r *Request) (*Response, error) {
-	return PlayPipeline.Run(r.Session, r.Player, nil)
+	if r.Engine != nil && !r.Session.Votable.Required() &&
+		r.Session.Playable.IsPlaying(EngineID) {
+		response, err := EnginePipeline.Run(r.Session, r.Player, nil)
+		if err != nil {
+			return nil, err
+		}
+		return withEngineMove(r, response)
+	}
+	response, err := PlayPipeline.Run(r.Session, r.Player, nil)
+	if err != nil || response != nil {
+		return response, err
+	}
+	return NewTextResponse(fmt.Sprintf(
+		"no votes to pick in game %d", r.Session.Storable.ID(),
+	)), nil
 }
 
 // ShowCommand is a command to show the game board
@@ -63,19 +108,273 @@ func (c *ShowCommand) Execute(r *Request) (*Response, error) {
 
 // ListCommand is a command to list available games
 type ListCommand struct {
-	All bool
+	All      bool
+	Archived bool
+	// Mine lists only the games of the player asking
+	Mine bool
+	// Waiting lists only the games waiting for the player asking to move
+	Waiting bool
+	// Player lists only the games of this player
+	Player string
+	// Page of the list to show, counting from 1
+	Page int
 }
 
-// Execute a list command to show games
+// Execute a list command to show a page of games
 func (c *ListCommand) Execute(r *Request) (*Response, error) {
-	list := []string{}
+	if r.Total == 0 {
+		return NewTextResponse("no games found"), nil
+	}
+	page := c.page()
+	pages := (r.Total + ListPageSize - 1) / ListPageSize
+	if page > pages {
+		return nil, fmt.Errorf("there are only %s", plural(pages, "page"))
+	}
+	lines := []string{}
 	for _, sess := range r.List {
-		id := sess.Storable.ID()
-		fin := sess.Game.Finished()
-		list = append(list, fmt.Sprintf("%d: finished: %t", id, fin))
+		sess.Lock()
+		summary := sess.Game.Summary()
+		sess.Unlock()
+		lines = append(lines, summary.Describe(r.names()))
 	}
-	if len(list) == 0 {
-		return NewTextResponse("no games found"), nil
+	if pages > 1 {
+		footer := fmt.Sprintf("page %d of %d", page, pages)
+		if page < pages {
+			footer += fmt.Sprintf(", say %s %d for more", c.words(), page+1)
+		}
+		lines = append(lines, footer)
+	}
+	return NewTextResponse(strings.Join(lines, "\n")), nil
+}
+
+// Filter picks the games on the page to list for the player asking
+func (c *ListCommand) Filter(player string) Filter {
+	f := Filter{
+		All:      c.All,
+		Archived: c.Archived,
+		Player:   c.Player,
+		Offset:   (c.page() - 1) * ListPageSize,
+		Limit:    ListPageSize,
+	}
+	if c.Mine {
+		f.Player = player
+	}
+	if c.Waiting {
+		f.Waiting = player
+	}
+	return f
+}
+
+// page of the list to show, counting from 1
+func (c *ListCommand) page() int {
+	if c.Page < 1 {
+		return 1
+	}
+	return c.Page
+}
+
+// words are what to say to list the same games again
+func (c *ListCommand) words() string {
+	switch {
+	case c.All:
+		return "list all"
+	case c.Archived:
+		return "list archived"
+	case c.Mine:
+		return "list mine"
+	case c.Waiting:
+		return "list waiting"
+	case c.Player != "":
+		return "list @" + c.Player
+	}
+	return "list"
+}
+
+// SetCommand is a command to change the settings of a game
+type SetCommand struct {
+	Locator  Locator
+	Setting  string
+	Duration time.Duration
+	Window   *Window
+}
+
+// Execute a set command to change game settings
+func (c *SetCommand) Execute(r *Request) (*Response, error) {
+	if !r.Admin {
+		return nil, errors.New("only admins can change settings")
+	}
+	_, err := SettingsPipeline.Run(r.Session, r.Player, nil)
+	if err != nil {
+		return nil, err
+	}
+	rules := r.Session.Votable.Rules()
+	switch c.Setting {
+	case "vote-duration":
+		rules.Duration = c.Duration
+	case "vote-window":
+		rules.Window = c.Window
+	}
+	err = r.Session.Votable.SetRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	r.Session.Storable.Record(Event{
+		Kind: RulesEvent, Player: r.Player, Voting: &rules,
+	})
+	// restart the vote timer so the new rules apply right away
+	r.Session.Votable.Schedule()
+	id := r.Session.Storable.ID()
+	return NewTextResponse(fmt.Sprintf("game %d: %s", id, rules.String())), nil
+}
+
+// LogCommand is a command to show what happened recently in a game
+type LogCommand struct {
+	Locator Locator
+}
+
+// Execute a log command to show the recent events of a game
+func (c *LogCommand) Execute(r *Request) (*Response, error) {
+	id := r.Session.Storable.ID()
+	events, err := r.Store.Events(id, LogLength)
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{fmt.Sprintf("game %d:", id)}
+	for _, e := range events {
+		lines = append(lines, e.Describe(r.names()))
+	}
+	if len(events) == 0 {
+		lines = append(lines, "nothing has happened yet")
+	}
+	return NewTextResponse(strings.Join(lines, "\n")), nil
+}
+
+// ArchiveCommand is a command to archive a game so it is no longer listed
+type ArchiveCommand struct {
+	Locator Locator
+}
+
+// Execute an archive command to put a game away
+func (c *ArchiveCommand) Execute(r *Request) (*Response, error) {
+	if !r.Admin && !r.Session.Playable.IsPlaying(r.Player) {
+		return nil, errors.New("only players in the game can archive it")
+	}
+	return ArchivePipeline.Run(r.Session, r.Player, nil)
+}
+
+// DeleteCommand is a command to delete a game and its log for good
+type DeleteCommand struct {
+	Locator Locator
+}
+
+// Execute a delete command to remove a game. The game is not loaded, the
+// store locks and stops it if it is being played.
+func (c *DeleteCommand) Execute(r *Request) (*Response, error) {
+	if !r.Admin {
+		return nil, errors.New("only admins can delete games")
+	}
+	id := c.Locator.ID
+	err := r.Store.Delete(id)
+	if err != nil {
+		return nil, err
+	}
+	return NewTextResponse(fmt.Sprintf("game %d deleted", id)), nil
+}
+
+// HintCommand is a command to suggest moves for the player to move
+type HintCommand struct {
+	Locator Locator
+	// Private sends the hint only to the player asking
+	Private bool
+}
+
+// Execute a hint command to suggest moves
+func (c *HintCommand) Execute(r *Request) (*Response, error) {
+	_, err := HintPipeline.Run(r.Session, r.Player, nil)
+	if err != nil {
+		return nil, err
+	}
+	private := ""
+	if c.Private {
+		private = r.Player
+	}
+	return hint(r.Session, r.Analyzer, private)
+}
+
+// EstimateCommand is a command to guess the score of a game
+type EstimateCommand struct {
+	Locator Locator
+}
+
+// Execute an estimate command to show who owns what on the board
+func (c *EstimateCommand) Execute(r *Request) (*Response, error) {
+	return EstimatePipeline.Run(r.Session, r.Player, nil)
+}
+
+// ReviewCommand is a command to review a finished game
+type ReviewCommand struct {
+	Locator Locator
+}
+
+// Execute a review command. The review is posted once it is done.
+func (c *ReviewCommand) Execute(r *Request) (*Response, error) {
+	_, err := ReviewPipeline.Run(r.Session, r.Player, nil)
+	if err != nil {
+		return nil, err
+	}
+	return review(r.Session, r.Evaluator, r.reviews)
+}
+
+// withEngineMove lets the engine take its turn after a move. Engines can be
+// slow, so the response is sent first and the engine moves afterwards without
+// the session lock. The move before stands if the engine fails, and the
+// engine can be asked again with the play command.
+func withEngineMove(r *Request, response *Response) (*Response, error) {
+	sess, engine, store := r.Session, r.Engine, r.Store
+	if !engineTurn(sess, engine) {
+		return response, nil
+	}
+	history, next := sess.Game.Position()
+	history = append(History{}, history...)
+	channel := sess.Playable.Channel()
+	response.Later = func(ctx context.Context) []*Response {
+		m, err := engine.GenMove(history, next)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var played *Response
+		if err == nil {
+			played, err = playEngineMove(sess, engine, store, history, m)
+		}
+		if err != nil {
+			failed := NewTextResponse(fmt.Sprintf(
+				"engine could not move (%s), say play to retry", err.Error(),
+			))
+			failed.Channel = channel
+			return []*Response{failed}
+		}
+		if played == nil {
+			return nil
+		}
+		return []*Response{played}
+	}
+	return response, nil
+}
+
+// playEngineMove plays the move the engine picked and saves the game.
+// Returns nothing if the game changed while the engine was thinking.
+func playEngineMove(
+	sess *Session, e Engine, store Store, history History, m *Move,
+) (*Response, error) {
+	sess.Lock()
+	defer sess.Unlock()
+	played, err := engineMove(sess, e, history, m)
+	if err != nil || played == "" {
+		return nil, err
+	}
+	err = store.Save(sess.Storable)
+	if err != nil {
+		return nil, err
 	}
-	return NewTextResponse(strings.Join(list, "\n")), nil
+	return NewSessionResponse(sess, played), nil
 }
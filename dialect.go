@@ -0,0 +1,109 @@
+package gobot
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Querier runs queries on a database or in a transaction
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Dialect adapts the SQL used by the store to a particular database
+type Dialect interface {
+	// Rebind rewrites ? placeholders into the style the database expects
+	Rebind(query string) string
+	// Insert runs an INSERT statement and returns the id of the new row
+	Insert(q Querier, query string, args ...interface{}) (int64, error)
+	// Migrations creates and upgrades the schema in this database
+	Migrations() []Migration
+	// Restored catches id generators up after rows were inserted with
+	// explicit ids
+	Restored(q Querier) error
+}
+
+var (
+	// SQLite is the dialect of the sqlite3 driver
+	SQLite Dialect = sqliteDialect{}
+	// Postgres is the dialect of the postgres driver
+	Postgres Dialect = postgresDialect{}
+)
+
+// DialectFor looks up the dialect of a database/sql driver name
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case "sqlite3":
+		return SQLite, nil
+	case "postgres":
+		return Postgres, nil
+	}
+	return nil, fmt.Errorf("unsupported database driver %s", driver)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) Insert(
+	q Querier, query string, args ...interface{},
+) (int64, error) {
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (sqliteDialect) Migrations() []Migration {
+	return Migrations
+}
+
+// Restored does nothing since AUTOINCREMENT keeps track of the largest id
+func (sqliteDialect) Restored(q Querier) error {
+	return nil
+}
+
+type postgresDialect struct{}
+
+// Rebind numbers the placeholders, e.g. $1, $2
+func (postgresDialect) Rebind(query string) string {
+	parts := strings.Split(query, "?")
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteString("$" + strconv.Itoa(i))
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// Insert uses RETURNING because the postgres driver does not support
+// LastInsertId.
+func (d postgresDialect) Insert(
+	q Querier, query string, args ...interface{},
+) (int64, error) {
+	var id int64
+	row := q.QueryRow(d.Rebind(query)+" RETURNING id", args...)
+	err := row.Scan(&id)
+	return id, err
+}
+
+func (postgresDialect) Migrations() []Migration {
+	return PostgresMigrations
+}
+
+// Restored moves the games sequence past the largest id
+func (postgresDialect) Restored(q Querier) error {
+	_, err := q.Exec(`
+		SELECT setval(pg_get_serial_sequence('games', 'id'), MAX(id))
+		FROM games
+	`)
+	return err
+}
@@ -0,0 +1,83 @@
+package gobot_test
+
+import (
+	"testing"
+
+	. "github.com/crestonbunch/gobot"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestRebind(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		input   string
+		expect  string
+	}{
+		{
+			dialect: SQLite,
+			input:   `UPDATE games SET blob = ? WHERE id = ? AND version = ?`,
+			expect:  `UPDATE games SET blob = ? WHERE id = ? AND version = ?`,
+		}, {
+			dialect: Postgres,
+			input:   `UPDATE games SET blob = ? WHERE id = ? AND version = ?`,
+			expect:  `UPDATE games SET blob = $1 WHERE id = $2 AND version = $3`,
+		}, {
+			dialect: Postgres,
+			input:   `SELECT id FROM games ORDER BY updated_at DESC`,
+			expect:  `SELECT id FROM games ORDER BY updated_at DESC`,
+		}, {
+			dialect: Postgres,
+			input:   `VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			expect:  `VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		},
+	}
+	for _, test := range cases {
+		if actual := test.dialect.Rebind(test.input); actual != test.expect {
+			t.Errorf("expected %q but got %q", test.expect, actual)
+		}
+	}
+}
+
+func TestDialectInsert(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		setup   func(sqlmock.Sqlmock)
+	}{
+		{
+			dialect: SQLite,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`^INSERT INTO games \(blob\) VALUES\(\?\)$`).
+					WithArgs("{}").
+					WillReturnResult(sqlmock.NewResult(7, 1))
+			},
+		}, {
+			dialect: Postgres,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(
+					`^INSERT INTO games \(blob\) VALUES\(\$1\) RETURNING id$`,
+				).
+					WithArgs("{}").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			},
+		},
+	}
+	for _, test := range cases {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		defer db.Close()
+		test.setup(mock)
+		id, err := test.dialect.Insert(
+			db, `INSERT INTO games (blob) VALUES(?)`, "{}",
+		)
+		if err != nil {
+			t.Errorf(err.Error())
+		} else if id != 7 {
+			t.Errorf("expected id 7 but got %d", id)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf(err.Error())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package gobot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// NameTTL is how long a looked up display name is remembered
+const NameTTL = time.Hour
+
+// Directory looks up the names users go by. Games keep user ids, which never
+// change, and only show names to people.
+type Directory interface {
+	// Name of a user, or the id if the user is unknown
+	Name(id string) string
+}
+
+// Mentions shows users as Slack mentions, which Slack displays as their
+// names. It is the default directory.
+type Mentions struct{}
+
+// Name implements the Directory interface
+func (Mentions) Name(id string) string {
+	return "<@" + id + ">"
+}
+
+// UserIDs shows users by their ids, e.g. in files read outside of Slack
+type UserIDs struct{}
+
+// Name implements the Directory interface
+func (UserIDs) Name(id string) string {
+	return id
+}
+
+// UserLookup finds a Slack user, e.g. a *slack.Client calling users.info
+type UserLookup interface {
+	GetUserInfo(id string) (*slack.User, error)
+}
+
+// SlackDirectory looks up display names in Slack and remembers them for
+// NameTTL
+type SlackDirectory struct {
+	API   UserLookup
+	Clock Clock
+	mu    sync.Mutex
+	names map[string]cachedName
+}
+
+type cachedName struct {
+	name    string
+	fetched time.Time
+}
+
+// NewSlackDirectory creates a directory that looks users up in Slack
+func NewSlackDirectory(api UserLookup) *SlackDirectory {
+	return &SlackDirectory{
+		API:   api,
+		Clock: SystemClock{},
+		names: map[string]cachedName{},
+	}
+}
+
+// Name implements the Directory interface. Users that cannot be looked up
+// are shown by id and looked up again next time.
+func (d *SlackDirectory) Name(id string) string {
+	now := d.Clock.Now()
+	d.mu.Lock()
+	cached, ok := d.names[id]
+	d.mu.Unlock()
+	if ok && now.Sub(cached.fetched) < NameTTL {
+		return cached.name
+	}
+	user, err := d.API.GetUserInfo(id)
+	if err != nil {
+		if ok {
+			// better an old name than none
+			return cached.name
+		}
+		return id
+	}
+	name := user.Profile.DisplayName
+	if name == "" {
+		name = user.RealName
+	}
+	if name == "" {
+		name = user.Name
+	}
+	if name == "" {
+		name = id
+	}
+	d.mu.Lock()
+	d.names[id] = cachedName{name: name, fetched: now}
+	d.mu.Unlock()
+	return name
+}
+
+// names formats a list of users with a directory
+func names(d Directory, users []string) []string {
+	output := make([]string, len(users))
+	for i, u := range users {
+		output[i] = d.Name(u)
+	}
+	return output
+}
@@ -0,0 +1,92 @@
+package gobot_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+	"github.com/nlopes/slack"
+)
+
+// fakeUsers answers users.info from a map and counts the lookups
+type fakeUsers struct {
+	users   map[string]*slack.User
+	fail    bool
+	lookups int
+}
+
+func (f *fakeUsers) GetUserInfo(id string) (*slack.User, error) {
+	f.lookups++
+	user, ok := f.users[id]
+	if !ok || f.fail {
+		return nil, errors.New("user_not_found")
+	}
+	return user, nil
+}
+
+func TestSlackDirectory(t *testing.T) {
+	shusaku := &slack.User{Name: "shusaku", RealName: "Honinbo Shusaku"}
+	shusaku.Profile.DisplayName = "Shusaku"
+	users := &fakeUsers{users: map[string]*slack.User{
+		"U1": shusaku,
+		"U2": {Name: "goseigen", RealName: "Go Seigen"},
+		"U3": {Name: "jowa"},
+	}}
+	clock := NewFakeClock(time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC))
+	d := NewSlackDirectory(users)
+	d.Clock = clock
+
+	cases := []struct {
+		id       string
+		expected string
+	}{
+		{"U1", "Shusaku"},
+		{"U2", "Go Seigen"},
+		{"U3", "jowa"},
+		{"U4", "U4"},
+	}
+	for _, test := range cases {
+		if actual := d.Name(test.id); actual != test.expected {
+			t.Errorf("expected %s to be %q but got %q", test.id, test.expected,
+				actual)
+		}
+	}
+	if users.lookups != 4 {
+		t.Errorf("expected 4 lookups but got %d", users.lookups)
+	}
+
+	// known names are remembered, unknown users are looked up again
+	d.Name("U1")
+	d.Name("U4")
+	if users.lookups != 5 {
+		t.Errorf("expected 5 lookups but got %d", users.lookups)
+	}
+
+	// old names are looked up again, but kept if Slack fails
+	clock.Advance(NameTTL)
+	users.fail = true
+	if actual := d.Name("U1"); actual != "Shusaku" {
+		t.Errorf("expected the old name but got %q", actual)
+	}
+	if users.lookups != 6 {
+		t.Errorf("expected 6 lookups but got %d", users.lookups)
+	}
+}
+
+func TestDirectoryDescribe(t *testing.T) {
+	summary := Summary{
+		ID:      2,
+		Players: Players{Black: []string{"U1"}, White: []string{"U2"}},
+	}
+	expected := "2: U1 vs U2, two-player, 0 moves, black to play, " +
+		"captures B 0 W 0, active just now"
+	if actual := summary.Describe(UserIDs{}); actual != expected {
+		t.Errorf("expected %q but got %q", expected, actual)
+	}
+	event := Event{Kind: PassEvent, Player: "U1"}
+	expected = "0001-01-01 00:00 U1 passed"
+	if actual := event.Describe(UserIDs{}); actual != expected {
+		t.Errorf("expected %q but got %q", expected, actual)
+	}
+}
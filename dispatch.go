@@ -0,0 +1,150 @@
+package gobot
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Transport delivers responses to a destination such as a Slack channel
+type Transport interface {
+	// Send a response to a destination
+	Send(dest string, r *Response) error
+}
+
+// Dispatcher queues responses and sends them with one worker per
+// destination, so a slow destination never holds up the others. Responses
+// to the same destination are sent in the order they were dispatched.
+// Failed sends are retried with exponential backoff.
+type Dispatcher struct {
+	// Attempts is how many times a response is sent before giving up
+	Attempts int
+	// Backoff is how long to wait before the first retry. It doubles after
+	// every failed attempt.
+	Backoff   time.Duration
+	transport Transport
+	clock     Clock
+	logger    *log.Logger
+	mu        sync.Mutex
+	queues    map[string]*replyQueue
+	closed    bool
+	workers   sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher that sends responses over a transport
+func NewDispatcher(t Transport, c Clock, l *log.Logger) *Dispatcher {
+	return &Dispatcher{
+		Attempts:  5,
+		Backoff:   time.Second,
+		transport: t,
+		clock:     c,
+		logger:    l,
+		queues:    map[string]*replyQueue{},
+	}
+}
+
+// Dispatch queues a response for a destination without blocking. Responses
+// dispatched after the dispatcher is closed are dropped.
+func (d *Dispatcher) Dispatch(dest string, r *Response) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		d.logger.Printf("dropping reply to %s after close", dest)
+		return
+	}
+	q, ok := d.queues[dest]
+	if !ok {
+		q = newReplyQueue()
+		d.queues[dest] = q
+		d.workers.Add(1)
+		go d.work(dest, q)
+	}
+	q.push(r)
+}
+
+// Close stops accepting responses and waits for the queued ones to be sent
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	d.closed = true
+	for _, q := range d.queues {
+		q.close()
+	}
+	d.mu.Unlock()
+	d.workers.Wait()
+}
+
+func (d *Dispatcher) work(dest string, q *replyQueue) {
+	defer d.workers.Done()
+	for {
+		r, ok := q.pop()
+		if !ok {
+			return
+		}
+		d.send(dest, r)
+	}
+}
+
+func (d *Dispatcher) send(dest string, r *Response) {
+	wait := d.Backoff
+	for attempt := 1; ; attempt++ {
+		err := d.transport.Send(dest, r)
+		if err == nil {
+			return
+		}
+		if attempt >= d.Attempts {
+			d.logger.Printf(
+				"giving up on reply to %s after %d attempts: %s",
+				dest, attempt, err,
+			)
+			return
+		}
+		d.logger.Printf("retrying reply to %s in %s: %s", dest, wait, err)
+		<-d.clock.NewTimer(wait).Chan()
+		wait *= 2
+	}
+}
+
+// replyQueue is an unbounded FIFO queue of responses
+type replyQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  []*Response
+	closed bool
+}
+
+func newReplyQueue() *replyQueue {
+	q := &replyQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *replyQueue) push(r *Response) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = append(q.items, r)
+	q.cond.Signal()
+}
+
+// pop blocks until a response is queued. Returns false once the queue is
+// closed and empty.
+func (q *replyQueue) pop() (*Response, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	r := q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	return r, true
+}
+
+func (q *replyQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,142 @@
+package gobot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+// fakeTransport records what was sent to each destination
+type fakeTransport struct {
+	mu       sync.Mutex
+	sent     map[string][]string
+	failures map[string]int
+	block    map[string]chan struct{}
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{
+		sent:     map[string][]string{},
+		failures: map[string]int{},
+		block:    map[string]chan struct{}{},
+	}
+}
+
+func (f *fakeTransport) Send(dest string, r *Response) error {
+	f.mu.Lock()
+	block := f.block[dest]
+	f.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures[dest] > 0 {
+		f.failures[dest]--
+		return errors.New("connection reset")
+	}
+	f.sent[dest] = append(f.sent[dest], r.Text)
+	return nil
+}
+
+func (f *fakeTransport) get(dest string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.sent[dest]...)
+}
+
+func newTestDispatcher(t Transport) *Dispatcher {
+	d := NewDispatcher(t, SystemClock{}, log.New(ioutil.Discard, "", 0))
+	d.Backoff = time.Millisecond
+	return d
+}
+
+func TestDispatcherOrdering(t *testing.T) {
+	transport := newFakeTransport()
+	d := newTestDispatcher(transport)
+	expect := []string{}
+	for _, text := range []string{"a", "b", "c", "d", "e"} {
+		d.Dispatch("C1", NewTextResponse(text))
+		expect = append(expect, text)
+	}
+	d.Close()
+	if actual := transport.get("C1"); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expected %v but got %v", expect, actual)
+	}
+}
+
+func TestDispatcherSlowDestination(t *testing.T) {
+	transport := newFakeTransport()
+	release := make(chan struct{})
+	transport.block["slow"] = release
+	d := newTestDispatcher(transport)
+
+	d.Dispatch("slow", NewTextResponse("upload"))
+	done := make(chan struct{})
+	go func() {
+		d.Dispatch("slow", NewTextResponse("queued"))
+		d.Dispatch("fast", NewTextResponse("text"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("dispatch blocked on a slow destination")
+	}
+	deadline := time.After(time.Second)
+	for len(transport.get("fast")) == 0 {
+		select {
+		case <-deadline:
+			t.Fatalf("fast destination waited for slow destination")
+		default:
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(release)
+	d.Close()
+	expect := []string{"upload", "queued"}
+	if actual := transport.get("slow"); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expected %v but got %v", expect, actual)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	cases := []struct {
+		failures int
+		expect   []string
+	}{
+		{failures: 0, expect: []string{"hi"}},
+		{failures: 4, expect: []string{"hi"}},
+		{failures: 5, expect: []string{}},
+	}
+	for _, test := range cases {
+		transport := newFakeTransport()
+		transport.failures["C1"] = test.failures
+		d := newTestDispatcher(transport)
+		d.Dispatch("C1", NewTextResponse("hi"))
+		d.Close()
+		actual := transport.get("C1")
+		if !reflect.DeepEqual(actual, test.expect) {
+			t.Errorf(
+				"%d failures: expected %v but got %v",
+				test.failures, test.expect, actual,
+			)
+		}
+	}
+}
+
+func TestDispatcherClosed(t *testing.T) {
+	transport := newFakeTransport()
+	d := newTestDispatcher(transport)
+	d.Close()
+	d.Dispatch("C1", NewTextResponse("late"))
+	if actual := transport.get("C1"); len(actual) != 0 {
+		t.Errorf("expected nothing sent after close but got %v", actual)
+	}
+}
@@ -0,0 +1,212 @@
+package gobot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EstimateDilations and EstimateErosions are how many times stones spread
+// their influence, and how many times contested influence is worn away,
+// when estimating territory (Bouzy's algorithm)
+const (
+	EstimateDilations = 5
+	EstimateErosions  = 21
+)
+
+// stoneInfluence is the influence a stone starts with, which is more than
+// the erosions can wear away
+const stoneInfluence = 128
+
+// Estimate is a guess at the score of a game in progress
+type Estimate struct {
+	// Owner of each point, indexed like the board. Empty points that no one
+	// owns yet are EmptyStone.
+	Owner [][]Stone
+	// Territory is the empty points each color owns, including the points of
+	// dead stones
+	Territory Captures
+	Captures  Captures
+	// Dead are the stones suggested to be dead, which count as captures too
+	Dead []Coords
+	Komi float64
+}
+
+// Influence spreads the influence of the stones on the board with Bouzy's
+// dilation and erosion. Black is positive and white is negative, indexed
+// like the board.
+func (b Board) Influence(dilations, erosions int) [][]int {
+	v := make([][]int, len(b))
+	for y := range b {
+		v[y] = make([]int, len(b[y]))
+		for x := range b[y] {
+			switch b.Get(x, y) {
+			case BlackStone:
+				v[y][x] = stoneInfluence
+			case WhiteStone:
+				v[y][x] = -stoneInfluence
+			}
+		}
+	}
+	for i := 0; i < dilations; i++ {
+		v = dilate(v)
+	}
+	for i := 0; i < erosions; i++ {
+		v = erode(v)
+	}
+	return v
+}
+
+// neighborSigns counts the neighbors of a point with positive, negative and
+// zero influence
+func neighborSigns(v [][]int, x, y int) (positive, negative, zero int) {
+	for _, n := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+		nx, ny := n[0], n[1]
+		if ny < 0 || ny >= len(v) || nx < 0 || nx >= len(v[ny]) {
+			continue
+		}
+		switch {
+		case v[ny][nx] > 0:
+			positive++
+		case v[ny][nx] < 0:
+			negative++
+		default:
+			zero++
+		}
+	}
+	return positive, negative, zero
+}
+
+// dilate grows influence into points that are not contested
+func dilate(v [][]int) [][]int {
+	next := make([][]int, len(v))
+	for y := range v {
+		next[y] = make([]int, len(v[y]))
+		for x := range v[y] {
+			positive, negative, _ := neighborSigns(v, x, y)
+			next[y][x] = v[y][x]
+			if v[y][x] >= 0 && negative == 0 {
+				next[y][x] += positive
+			} else if v[y][x] <= 0 && positive == 0 {
+				next[y][x] -= negative
+			}
+		}
+	}
+	return next
+}
+
+// erode wears influence away where it borders on other influence or none
+func erode(v [][]int) [][]int {
+	next := make([][]int, len(v))
+	for y := range v {
+		next[y] = make([]int, len(v[y]))
+		for x := range v[y] {
+			positive, negative, zero := neighborSigns(v, x, y)
+			switch {
+			case v[y][x] > 0:
+				next[y][x] = v[y][x] - negative - zero
+				if next[y][x] < 0 {
+					next[y][x] = 0
+				}
+			case v[y][x] < 0:
+				next[y][x] = v[y][x] + positive + zero
+				if next[y][x] > 0 {
+					next[y][x] = 0
+				}
+			}
+		}
+	}
+	return next
+}
+
+// Estimate guesses who owns each point of the board. Stones that look dead
+// are taken off and belong to the opponent, and empty points belong to
+// whoever has influence over them, unless they are next to stones in seki.
+func (b Board) Estimate(captures Captures, komi float64) Estimate {
+	e := Estimate{
+		Owner:    make([][]Stone, len(b)),
+		Captures: captures,
+		Dead:     b.DeadStones(),
+		Komi:     komi,
+	}
+	dead := map[Coords]bool{}
+	for _, p := range e.Dead {
+		dead[p] = true
+	}
+	alive := b.RemoveStones(e.Dead)
+	v := alive.Influence(EstimateDilations, EstimateErosions)
+	territory := b.territory(func(x, y int) Stone {
+		switch {
+		case v[y][x] > 0:
+			return BlackStone
+		case v[y][x] < 0:
+			return WhiteStone
+		}
+		return EmptyStone
+	})
+	for y := range b {
+		e.Owner[y] = make([]Stone, len(b[y]))
+		for x := range b[y] {
+			stone := alive.Get(x, y)
+			switch {
+			case dead[Coords{x, y}] && b.Get(x, y) == BlackStone:
+				e.Owner[y][x] = WhiteStone
+				e.Territory.White++
+				e.Captures.White++
+			case dead[Coords{x, y}]:
+				e.Owner[y][x] = BlackStone
+				e.Territory.Black++
+				e.Captures.Black++
+			case stone != EmptyStone:
+				e.Owner[y][x] = stone
+			case territory[y][x] == BlackStone:
+				e.Owner[y][x] = BlackStone
+				e.Territory.Black++
+			case territory[y][x] == WhiteStone:
+				e.Owner[y][x] = WhiteStone
+				e.Territory.White++
+			}
+		}
+	}
+	return e
+}
+
+// Margin is how many points black is ahead by, counting territory and
+// captures, with komi for white. It is negative if white is ahead.
+func (e Estimate) Margin() float64 {
+	black := e.Territory.Black + e.Captures.Black
+	white := e.Territory.White + e.Captures.White
+	return float64(black) - float64(white) - e.Komi
+}
+
+// String implements the stringer interface
+func (e Estimate) String() string {
+	margin := e.Margin()
+	lead := "even"
+	switch {
+	case margin > 0:
+		lead = "black leads by " + formatPoints(margin)
+	case margin < 0:
+		lead = "white leads by " + formatPoints(-margin)
+	}
+	parts := []string{
+		lead,
+		fmt.Sprintf("territory B %d W %d", e.Territory.Black, e.Territory.White),
+		fmt.Sprintf("captures B %d W %d", e.Captures.Black, e.Captures.White),
+		"komi " + formatPoints(e.Komi),
+	}
+	if len(e.Dead) > 0 {
+		parts = append(parts, "dead "+pointList(e.Dead))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// pointList names points in order, separated by spaces
+func pointList(points []Coords) string {
+	names := []string{}
+	for _, p := range points {
+		names = append(names, p.String())
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
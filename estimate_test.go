@@ -0,0 +1,80 @@
+package gobot_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestEstimate(t *testing.T) {
+	cases := []struct {
+		board  string
+		owner  string
+		margin float64
+		text   string
+	}{
+		{
+			".B.W./.B.W./.B.W./.B.W./.B.W.",
+			"BB.WW/BB.WW/BB.WW/BB.WW/BB.WW",
+			-1.5,
+			"white leads by 1.5, territory B 5 W 5, captures B 1 W 2, komi 0.5",
+		}, {
+			"...../...../..B../...../.....",
+			"BBBBB/BBBBB/BBBBB/BBBBB/BBBBB",
+			22.5,
+			"black leads by 22.5, territory B 24 W 0, captures B 1 W 2, komi 0.5",
+		}, {
+			// influence that meets in the middle is worn away
+			"...../.B.W./...../.B.W./.....",
+			"...../BB.WW/B...W/BB.WW/.....",
+			-1.5,
+			"white leads by 1.5, territory B 3 W 3, captures B 1 W 2, komi 0.5",
+		}, {
+			// the stones in seki are not dead and have no territory
+			sekis[0].board,
+			"BBW.BWW/BBWWBWW/BBW.BWW/BBWWBWW/BBBBWWW",
+			-1.5,
+			"white leads by 1.5, territory B 3 W 3, captures B 1 W 2, komi 0.5",
+		},
+	}
+	for _, test := range cases {
+		e := compactBoard(t, test.board).Estimate(Captures{1, 2}, 0.5)
+		owner := compactBoard(t, test.owner)
+		if !reflect.DeepEqual(Board(e.Owner), owner) {
+			t.Errorf("expected %s to be owned like %s but got %v", test.board,
+				test.owner, e.Owner)
+		}
+		if e.Margin() != test.margin {
+			t.Errorf("expected %s to have margin %v but got %v", test.board,
+				test.margin, e.Margin())
+		}
+		if e.String() != test.text {
+			t.Errorf("expected %q but got %q", test.text, e.String())
+		}
+	}
+}
+
+func TestServerEstimate(t *testing.T) {
+	server := newTestServer(t, "start U1 U2")
+	defer server.Close()
+	if err := server.Handle("move D4", "U1", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	for _, input := range []string{"estimate", "score 1"} {
+		if err := server.Handle(input, "U2", "C1"); err != nil {
+			t.Fatalf("%s: %s", input, err.Error())
+		}
+		r := <-server.Replies
+		// a lone stone is not enough to claim any territory
+		details := "white leads by 6.5, territory B 0 W 0, captures B 0 W 0, " +
+			"komi 6.5"
+		if r.Details != details {
+			t.Errorf("%s: expected %q but got %q", input, details, r.Details)
+		}
+		if len(r.Territory) != 19 || r.Territory[3][3] != BlackStone {
+			t.Errorf("%s: expected black to own D4 in %v", input, r.Territory)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package gobot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LogLength is how many events the log command shows
+const LogLength = 20
+
+// EventKind is the kind of thing that happened in a game
+type EventKind string
+
+const (
+	// CreatedEvent is recorded when a game is started
+	CreatedEvent EventKind = "created"
+	// MoveEvent is recorded when a player places a stone
+	MoveEvent EventKind = "move"
+	// PassEvent is recorded when a player passes
+	PassEvent EventKind = "pass"
+	// VoteEvent is recorded when a player votes for a move
+	VoteEvent EventKind = "vote"
+	// ResolvedEvent is recorded when a vote is picked and played
+	ResolvedEvent EventKind = "resolved"
+	// ArchivedEvent is recorded when a game is archived
+	ArchivedEvent EventKind = "archived"
+	// ResignEvent is recorded when a player resigns
+	ResignEvent EventKind = "resign"
+	// RulesEvent is recorded when the vote settings of a game change
+	RulesEvent EventKind = "rules"
+)
+
+// Event is something that happened in a game. Events are only ever
+// appended, so together they are a full history of the game.
+type Event struct {
+	Game   int64     `json:"game"`
+	Kind   EventKind `json:"kind"`
+	Player string    `json:"player,omitempty"`
+	Move   *Move     `json:"move,omitempty"`
+	At     time.Time `json:"at"`
+	// Blueprint the game was created from, only set on CreatedEvent
+	Blueprint *Blueprint `json:"blueprint,omitempty"`
+	// Voting rules the game changed to, only set on RulesEvent
+	Voting *Voting `json:"voting,omitempty"`
+}
+
+// NewMoveEvent records a move or pass by a player
+func NewMoveEvent(player string, m *Move) Event {
+	if m.Pass {
+		return Event{Kind: PassEvent, Player: player, Move: m}
+	}
+	return Event{Kind: MoveEvent, Player: player, Move: m}
+}
+
+// String implements the stringer interface
+func (e Event) String() string {
+	return e.Describe(Mentions{})
+}
+
+// Describe what happened, naming the player with a directory
+func (e Event) Describe(d Directory) string {
+	who := "someone"
+	if e.Player != "" {
+		who = d.Name(e.Player)
+	}
+	at := e.At.UTC().Format("2006-01-02 15:04")
+	switch e.Kind {
+	case CreatedEvent:
+		return fmt.Sprintf("%s %s started the game", at, who)
+	case MoveEvent:
+		return fmt.Sprintf("%s %s played %s", at, who, e.Move.Coords.String())
+	case PassEvent:
+		return fmt.Sprintf("%s %s passed", at, who)
+	case VoteEvent:
+		return fmt.Sprintf("%s %s voted to %s", at, who, e.Move.String())
+	case ResolvedEvent:
+		return fmt.Sprintf("%s voted to %s", at, e.Move.String())
+	case ArchivedEvent:
+		return fmt.Sprintf("%s %s archived the game", at, who)
+	case ResignEvent:
+		return fmt.Sprintf("%s %s resigned", at, who)
+	case RulesEvent:
+		return fmt.Sprintf("%s %s changed the rules to %s", at, who, e.Voting)
+	}
+	return fmt.Sprintf("%s %s %s", at, who, e.Kind)
+}
+
+// Replay rebuilds a game from its events, oldest first
+func Replay(events []Event) (*State, error) {
+	if len(events) == 0 || events[0].Kind != CreatedEvent ||
+		events[0].Blueprint == nil {
+		return nil, errors.New("game log does not start with its creation")
+	}
+	created := events[0]
+	game := newState(*created.Blueprint, created.At)
+	game.id = created.Game
+	for _, e := range events[1:] {
+		var err error
+		switch e.Kind {
+		case MoveEvent, PassEvent:
+			err = game.Move(e.Move)
+		case VoteEvent:
+			err = game.Vote(e.Move)
+		case ResolvedEvent:
+			err = game.Reset()
+			if err == nil {
+				err = game.Move(e.Move)
+			}
+		case ArchivedEvent:
+			at := e.At
+			game.ArchivedAt = &at
+		case ResignEvent:
+			err = game.Resign()
+		case RulesEvent:
+			if e.Voting == nil {
+				err = errors.New("missing rules")
+			} else {
+				err = game.SetRules(*e.Voting)
+			}
+		default:
+			err = fmt.Errorf("unknown event %s", e.Kind)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("replaying %s: %s", e.String(), err)
+		}
+		game.UpdatedAt = e.At
+	}
+	return game, nil
+}
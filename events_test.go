@@ -0,0 +1,161 @@
+package gobot_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestEventString(t *testing.T) {
+	at := time.Date(2017, 3, 4, 9, 30, 0, 0, time.UTC)
+	cases := []struct {
+		event    Event
+		expected string
+	}{
+		{
+			event:    Event{Kind: CreatedEvent, Player: "U1", At: at},
+			expected: "2017-03-04 09:30 <@U1> started the game",
+		}, {
+			event:    NewMoveEvent("U1", &Move{Coords: Coords{3, 2}}),
+			expected: "0001-01-01 00:00 <@U1> played C4",
+		}, {
+			event:    NewMoveEvent("U2", &Move{Pass: true}),
+			expected: "0001-01-01 00:00 <@U2> passed",
+		}, {
+			event: Event{
+				Kind: VoteEvent, Player: "U3", Move: &Move{Coords: Coords{0, 0}},
+			},
+			expected: "0001-01-01 00:00 <@U3> voted to move at A1",
+		}, {
+			event: Event{
+				Kind: ResolvedEvent, Move: &Move{Pass: true}, At: at,
+			},
+			expected: "2017-03-04 09:30 voted to pass",
+		}, {
+			event:    Event{Kind: ArchivedEvent, Player: "U1", At: at},
+			expected: "2017-03-04 09:30 <@U1> archived the game",
+		}, {
+			event:    Event{Kind: ResignEvent, Player: "U2", At: at},
+			expected: "2017-03-04 09:30 <@U2> resigned",
+		}, {
+			event: Event{
+				Kind: RulesEvent, Player: "U1", At: at,
+				Voting: &Voting{Required: true, Duration: 2 * time.Hour},
+			},
+			expected: "2017-03-04 09:30 <@U1> changed the rules to " +
+				"votes picked every 2h0m0s",
+		},
+	}
+	for _, test := range cases {
+		if actual := test.event.String(); actual != test.expected {
+			t.Errorf("expected %q but got %q", test.expected, actual)
+		}
+	}
+}
+
+func TestReplayNeedsCreation(t *testing.T) {
+	cases := [][]Event{
+		{},
+		{NewMoveEvent("U1", &Move{Pass: true})},
+		{{Kind: CreatedEvent}},
+	}
+	for _, events := range cases {
+		if _, err := Replay(events); err == nil {
+			t.Errorf("expected %v to fail to replay", events)
+		}
+	}
+}
+
+// TestReplay plays games through the server and checks the event log
+// rebuilds the same games
+func TestReplay(t *testing.T) {
+	clock := NewFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemoryStore("")
+	server := NewStoreServer(store)
+	server.Admins = []string{"U1"}
+	server.Use(clock, NewRNG(1))
+	if err := server.Start(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer server.Close()
+
+	commands := []struct {
+		input  string
+		player string
+	}{
+		{"start", "U1"},
+		{"vote 1 D4", "U2"},
+		{"vote 1 D4", "U3"},
+		{"play 1", "U1"},
+		{"vote 1 Q16", "U2"},
+		{"start U1 U2", "U1"},
+		{"move 2 D4", "U1"},
+		{"move 2 pass", "U2"},
+		{"move 2 E4", "U1"},
+		{"set 1 vote-duration 2h", "U1"},
+		{"vote 1 pass", "U3"},
+		{"resign 2", "U2"},
+	}
+	for _, c := range commands {
+		if err := server.Handle(c.input, c.player, "C1"); err != nil {
+			t.Fatalf("%s: %s", c.input, err.Error())
+		}
+		clock.Advance(time.Minute)
+	}
+
+	for _, id := range []int64{1, 2} {
+		events, err := store.Events(id, 0)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		replayed, err := Replay(events)
+		if err != nil {
+			t.Fatalf("game %d: %s", id, err.Error())
+		}
+		sess, err := store.Get(id)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		saved := sess.Game.(*State)
+		if !reflect.DeepEqual(replayed.History, saved.History) ||
+			!reflect.DeepEqual(replayed.Votes, saved.Votes) ||
+			replayed.Next != saved.Next || replayed.Passes != saved.Passes ||
+			replayed.Resigned != saved.Resigned ||
+			!reflect.DeepEqual(replayed.Players, saved.Players) ||
+			!reflect.DeepEqual(replayed.Voting, saved.Voting) ||
+			!replayed.CreatedAt.Equal(saved.CreatedAt) {
+			t.Errorf("game %d replayed differently", id)
+		}
+	}
+
+	if err := server.Handle("log 2", "U3", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []string{
+		"game 2:",
+		"<@U1> started the game",
+		"<@U1> played D4",
+		"<@U2> passed",
+		"<@U1> played E4",
+		"<@U2> resigned",
+	}
+	for {
+		r := <-server.Replies
+		if !strings.HasPrefix(r.Text, "game 2:") {
+			continue
+		}
+		lines := strings.Split(r.Text, "\n")
+		if len(lines) != len(expected) {
+			t.Fatalf("unexpected log\n%s", r.Text)
+		}
+		for i, line := range lines {
+			if !strings.HasSuffix(line, expected[i]) {
+				t.Errorf("expected %q to end with %q", line, expected[i])
+			}
+		}
+		break
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/crestonbunch/gobot"
+	"github.com/nlopes/slack"
+)
+
+// runCommand runs a subcommand instead of the bot
+func runCommand(args []string) error {
+	switch args[0] {
+	case "export":
+		return exportGames(args[1:])
+	case "import":
+		return importGames(args[1:])
+	case "gtp":
+		return serveGTP(args[1:])
+	}
+	return fmt.Errorf("unknown command %s, try export, import or gtp", args[0])
+}
+
+// serveGTP plays with a GTP controller over stdin and stdout, picking moves
+// with the engine named by the first argument
+func serveGTP(args []string) error {
+	engine := "random"
+	if len(args) > 0 {
+		engine = args[0]
+	}
+	seed := time.Now().UnixNano()
+	switch engine {
+	case "random":
+		rng := gobot.NewRNG(seed)
+		s := gobot.NewGTPServer(gobot.RandomEngine{RNG: rng})
+		return s.Serve(os.Stdin, os.Stdout)
+	case "mcts":
+		mcts, err := newMCTS(seed)
+		if err != nil {
+			return err
+		}
+		return gobot.NewGTPServer(mcts).Serve(os.Stdin, os.Stdout)
+	}
+	return fmt.Errorf("unknown engine %s, try random or mcts", engine)
+}
+
+// exportGames writes every game to the archive named by the first argument,
+// or to stdout if there is none
+func exportGames(args []string) error {
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	err = store.Load()
+	if err != nil {
+		return err
+	}
+	f := os.Stdout
+	if len(args) > 0 && args[0] != "-" {
+		f, err = os.Create(args[0])
+		if err != nil {
+			return err
+		}
+	}
+	count, err := gobot.Export(store, directory(), f)
+	if f != os.Stdout {
+		// a backup is only good if it was completely written
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("exported %d games", count)
+	return nil
+}
+
+// directory names players in exported games with their Slack display names
+// if SLACK_API_TOKEN is set, or by their ids otherwise
+func directory() gobot.Directory {
+	token := os.Getenv("SLACK_API_TOKEN")
+	if token == "" {
+		return gobot.UserIDs{}
+	}
+	return gobot.NewSlackDirectory(slack.New(token))
+}
+
+// importGames restores every game from the archive named by the first
+// argument, or from stdin if there is none
+func importGames(args []string) error {
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	err = store.Load()
+	if err != nil {
+		return err
+	}
+	var r io.Reader = os.Stdin
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	count, err := gobot.Import(store, r)
+	log.Printf("imported %d games", count)
+	return err
+}
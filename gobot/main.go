@@ -1,17 +1,31 @@
 package main
 
 import (
-	"database/sql"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/crestonbunch/gobot"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/nlopes/slack"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		err := runCommand(os.Args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	token := os.Getenv("SLACK_API_TOKEN")
 
 	logger := log.New(os.Stdout, "slack: ", log.Lshortfile|log.LstdFlags)
@@ -26,22 +40,107 @@ func main() {
 	}
 	defer i.Close()
 
-	db, err := sql.Open("sqlite3", "./games.db")
+	store, err := openStore()
 	if err != nil {
 		logger.Fatal(err)
 	}
-	bot, err := gobot.NewServer(db)
-	if err != nil {
-		logger.Fatal(err)
+	// set GOBOT_SEED to replay the votes picked in a previous run
+	seed := time.Now().UnixNano()
+	if env := os.Getenv("GOBOT_SEED"); env != "" {
+		seed, err = strconv.ParseInt(env, 10, 64)
+		if err != nil {
+			logger.Fatalf("bad GOBOT_SEED: %s", err.Error())
+		}
 	}
+	bot := gobot.NewSeededStoreServer(store, seed)
 	defer bot.Close()
+	bot.Admins = strings.Fields(os.Getenv("GOBOT_ADMINS"))
+	bot.Directory = i.Names
+	// play against the built-in engine with start @me engine, or set
+	// GOBOT_ENGINE to a GTP engine command, e.g. gnugo --mode gtp, to play
+	// against that instead
+	if command := strings.Fields(os.Getenv("GOBOT_ENGINE")); len(command) > 0 {
+		engine, err := gobot.StartGTP(command[0], command[1:]...)
+		if err != nil {
+			logger.Fatalf("error starting engine: %s", err.Error())
+		}
+		defer engine.Close()
+		bot.Engine = engine
+		bot.Analyzer = engine
+	} else {
+		engine, err := newMCTS(seed)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		bot.Engine = engine
+		bot.Analyzer = engine
+	}
+	// reviews always use the built-in engine, since GTP engines do not say
+	// who is winning
+	evaluator := gobot.NewMCTS(gobot.NewRNG(seed))
+	evaluator.Playouts = gobot.DefaultReviewPlayouts
+	bot.Evaluator = evaluator
+	// set GOBOT_ENGINE_VOTES=1 for the engine to vote in vote games when no
+	// one else has
+	if os.Getenv("GOBOT_ENGINE_VOTES") == "1" {
+		bot.Voter = bot.Engine
+	}
 
 	err = bot.Start()
 	if err != nil {
 		logger.Fatalf("error starting server: %s", err.Error())
 	}
 	go i.StartReceiving(bot)
-	go i.StartSending(bot)
+	sent := make(chan struct{})
+	go func() {
+		i.StartSending(bot)
+		close(sent)
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	logger.Printf("received %s, shutting down", sig)
+	// stop taking commands, then let the bot finish its work and wait for
+	// the remaining replies to be sent
+	i.Close()
+	err = bot.Close()
+	if err != nil {
+		logger.Printf("error shutting down: %s", err.Error())
+	}
+	<-sent
+}
+
+// openStore opens the configured store. Games are kept in ./games.db unless
+// another store is configured.
+func openStore() (gobot.Store, error) {
+	driver, source := os.Getenv("GOBOT_DB_DRIVER"), os.Getenv("GOBOT_DB")
+	if driver == "" {
+		driver = "sqlite3"
+	}
+	if driver == "sqlite3" && source == "" {
+		source = "./games.db"
+	}
+	return gobot.OpenStore(driver, source)
+}
 
-	i.Block()
+// newMCTS creates the built-in engine. Set GOBOT_PLAYOUTS and
+// GOBOT_THINK_TIME, e.g. 5s, to change how hard it thinks.
+func newMCTS(seed int64) (*gobot.MCTS, error) {
+	engine := gobot.NewMCTS(gobot.NewRNG(seed))
+	if env := os.Getenv("GOBOT_PLAYOUTS"); env != "" {
+		playouts, err := strconv.Atoi(env)
+		if err != nil || playouts < 1 {
+			return nil, fmt.Errorf("bad GOBOT_PLAYOUTS %q", env)
+		}
+		engine.Playouts = playouts
+	}
+	if env := os.Getenv("GOBOT_THINK_TIME"); env != "" {
+		d, err := time.ParseDuration(env)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("bad GOBOT_THINK_TIME %q", env)
+		}
+		engine.ThinkTime = d
+	}
+	return engine, nil
 }
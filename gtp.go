@@ -0,0 +1,280 @@
+package gobot
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EngineID is the player id of the engine in games started with an engine,
+// e.g. start @me engine
+const EngineID = "engine"
+
+// Komi is the compensation white gets for moving second
+const Komi = 6.5
+
+// gtpColumns are the column letters of GTP vertices, which skip I
+const gtpColumns = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+// Engine picks moves for a player
+type Engine interface {
+	// GenMove picks the next move for a color given the boards played so far
+	GenMove(history History, next Stone) (*Move, error)
+}
+
+// KomiSetter is an engine that can be told the komi of the game it plays
+type KomiSetter interface {
+	SetKomi(komi float64)
+}
+
+// GTPClient talks to a go engine using the Go Text Protocol, e.g. GNU Go
+// started with gnugo --mode gtp. It is safe to use from many games at once;
+// the engine is brought up to date with a game before each move it makes.
+type GTPClient struct {
+	Komi float64
+	cmd  *exec.Cmd
+	w    io.WriteCloser
+	r    *bufio.Reader
+	mu   sync.Mutex
+	// the boards the engine has been told about
+	synced History
+}
+
+// StartGTP starts an engine program and talks GTP to it over stdin and
+// stdout
+func StartGTP(name string, args ...string) (*GTPClient, error) {
+	cmd := exec.Command(name, args...)
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	c := NewGTPClient(r, w)
+	c.cmd = cmd
+	return c, nil
+}
+
+// NewGTPClient talks GTP to an engine that reads commands from w and writes
+// responses to r
+func NewGTPClient(r io.Reader, w io.WriteCloser) *GTPClient {
+	return &GTPClient{
+		Komi: Komi,
+		w:    w,
+		r:    bufio.NewReader(r),
+	}
+}
+
+// Command sends a command to the engine and returns its response
+func (c *GTPClient) Command(name string, args ...string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.command(name, args...)
+}
+
+// GenMove implements the Engine interface. An engine that resigns passes
+// instead, since games end when both players pass.
+func (c *GTPClient) GenMove(history History, next Stone) (*Move, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.sync(history)
+	if err != nil {
+		return nil, err
+	}
+	vertex, err := c.command("genmove", gtpColor(next))
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseGTPVertex(vertex)
+	if err != nil {
+		return nil, err
+	}
+	if m.Pass {
+		return m, nil
+	}
+	last := c.synced[len(c.synced)-1]
+	board, _, err := last.Play(m.Coords[0], m.Coords[1], next)
+	if err != nil {
+		// forget what the engine knows so it is set up again next time
+		c.synced = nil
+		return nil, fmt.Errorf("engine played %s: %s", vertex, err)
+	}
+	c.synced = append(c.synced, board)
+	return m, nil
+}
+
+// SetKomi implements the KomiSetter interface. The engine is told about it
+// when it is set up again before its next move.
+func (c *GTPClient) SetKomi(komi float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Komi = komi
+	c.synced = nil
+}
+
+// Close asks the engine to quit and waits for it to exit
+func (c *GTPClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.command("quit")
+	closeErr := c.w.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if c.cmd != nil {
+		waitErr := c.cmd.Wait()
+		if err == nil {
+			err = waitErr
+		}
+	}
+	return err
+}
+
+// sync tells the engine about the moves it has not seen yet. If the history
+// is not a continuation of what the engine knows, e.g. because it is another
+// game, the engine starts over from an empty board. The caller must hold the
+// lock.
+func (c *GTPClient) sync(history History) error {
+	n := len(c.synced)
+	if n == 0 || n > len(history) || !history[n-1].Equals(c.synced[n-1]) {
+		size := strconv.Itoa(len(history[0]))
+		komi := strconv.FormatFloat(c.Komi, 'f', -1, 64)
+		for _, args := range [][]string{
+			{"boardsize", size}, {"clear_board"}, {"komi", komi},
+		} {
+			_, err := c.command(args[0], args[1:]...)
+			if err != nil {
+				c.synced = nil
+				return err
+			}
+		}
+		c.synced = History{history[0]}
+		n = 1
+	}
+	for i := n; i < len(history); i++ {
+		stone, x, y, ok := placed(history[i-1], history[i])
+		if ok {
+			_, err := c.command("play", gtpColor(stone), GTPVertex(x, y))
+			if err != nil {
+				c.synced = nil
+				return err
+			}
+		}
+		c.synced = append(c.synced, history[i])
+	}
+	return nil
+}
+
+// command sends a command and reads the response up to the blank line that
+// ends it. The caller must hold the lock.
+func (c *GTPClient) command(name string, args ...string) (string, error) {
+	line := strings.Join(append([]string{name}, args...), " ")
+	_, err := io.WriteString(c.w, line+"\n")
+	if err != nil {
+		return "", err
+	}
+	lines := []string{}
+	for {
+		text, err := c.r.ReadString('\n')
+		text = strings.TrimRight(text, "\r\n")
+		if text == "" && len(lines) > 0 {
+			break
+		}
+		if text != "" {
+			lines = append(lines, text)
+		}
+		if err == io.EOF && (len(lines) > 0 || name == "quit") {
+			// the engine may exit before ending its last response
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", line, err)
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	response := strings.Join(lines, "\n")
+	switch {
+	case strings.HasPrefix(response, "="):
+		return strings.TrimSpace(response[1:]), nil
+	case strings.HasPrefix(response, "?"):
+		return "", fmt.Errorf("%s: %s", line, strings.TrimSpace(response[1:]))
+	}
+	return "", fmt.Errorf("%s: unexpected response %q", line, response)
+}
+
+// GTPVertex names a point in GTP, e.g. D4
+func GTPVertex(x, y int) string {
+	return string(gtpColumns[y]) + strconv.Itoa(x+1)
+}
+
+// ParseGTPVertex reads a move from a GTP vertex such as D4 or pass. Resigning
+// is read as a pass.
+func ParseGTPVertex(vertex string) (*Move, error) {
+	vertex = strings.ToUpper(strings.TrimSpace(vertex))
+	if vertex == "PASS" || vertex == "RESIGN" {
+		return &Move{Pass: true}, nil
+	}
+	if len(vertex) < 2 {
+		return nil, fmt.Errorf("bad vertex %q", vertex)
+	}
+	y := strings.IndexByte(gtpColumns, vertex[0])
+	number, err := strconv.Atoi(vertex[1:])
+	if y < 0 || err != nil || number < 1 {
+		return nil, fmt.Errorf("bad vertex %q", vertex)
+	}
+	return &Move{Coords: Coords{number - 1, y}}, nil
+}
+
+func gtpColor(s Stone) string {
+	if s == WhiteStone {
+		return "w"
+	}
+	return "b"
+}
+
+// engineTurn checks if it is the engine's turn in a two player game. The
+// caller must hold the session lock.
+func engineTurn(s *Session, e Engine) bool {
+	return e != nil && !s.deleted && !s.Votable.Required() &&
+		!s.Game.Finished() && !s.Game.Archived() && s.Playable.CanMove(EngineID)
+}
+
+// engineMove plays the move the engine picked after the given history.
+// Returns what the engine did, or nothing if the game moved on while the
+// engine was thinking. The caller must hold the session lock.
+func engineMove(
+	s *Session, e Engine, history History, m *Move,
+) (string, error) {
+	current, _ := s.Game.Position()
+	if !engineTurn(s, e) || len(current) != len(history) {
+		return "", nil
+	}
+	if !s.Game.Validate(m) {
+		return "", errors.New("engine picked an invalid move")
+	}
+	before := s.Game.Board()
+	_, next := s.Game.Position()
+	err := s.Game.Move(m)
+	if err != nil {
+		return "", err
+	}
+	s.Storable.Record(NewMoveEvent(EngineID, m))
+	if m.Pass {
+		return "engine passed" + describeEnd(s), nil
+	}
+	return "engine played " + m.Coords.String() +
+		describeConsequences(before, m, next), nil
+}
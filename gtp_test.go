@@ -0,0 +1,280 @@
+package gobot_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+// TestGTPHelperProcess is a stub engine started by the tests. It plays the
+// first empty point, suggests it with reg_genmove, and answers history with
+// the commands it received.
+func TestGTPHelperProcess(t *testing.T) {
+	if os.Getenv("GOBOT_GTP_HELPER") != "1" {
+		return
+	}
+	occupied := map[string]bool{}
+	received := []string{}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		received = append(received, fields[0])
+		response := "= \n\n"
+		switch fields[0] {
+		case "boardsize", "komi":
+		case "clear_board":
+			occupied = map[string]bool{}
+		case "play":
+			occupied[fields[2]] = true
+		case "genmove", "reg_genmove":
+			for _, vertex := range []string{"A1", "B1", "C1", "D1"} {
+				if !occupied[vertex] {
+					occupied[vertex] = fields[0] == "genmove"
+					response = "= " + vertex + "\n\n"
+					break
+				}
+			}
+		case "history":
+			response = "= " + strings.Join(received, " ") + "\n\n"
+		case "quit":
+			fmt.Print(response)
+			os.Exit(0)
+		default:
+			response = "? unknown command\n\n"
+		}
+		fmt.Print(response)
+	}
+	os.Exit(0)
+}
+
+func startStubEngine(t *testing.T) *GTPClient {
+	os.Setenv("GOBOT_GTP_HELPER", "1")
+	defer os.Unsetenv("GOBOT_GTP_HELPER")
+	c, err := StartGTP(os.Args[0], "-test.run=TestGTPHelperProcess")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return c
+}
+
+func TestGTPVertex(t *testing.T) {
+	cases := []struct {
+		vertex string
+		move   *Move
+		err    bool
+	}{
+		{vertex: "A1", move: &Move{Coords: Coords{0, 0}}},
+		{vertex: "J3", move: &Move{Coords: Coords{2, 8}}},
+		{vertex: "t19", move: &Move{Coords: Coords{18, 18}}},
+		{vertex: "pass", move: &Move{Pass: true}},
+		{vertex: "resign", move: &Move{Pass: true}},
+		{vertex: "I3", err: true},
+		{vertex: "D0", err: true},
+		{vertex: "D", err: true},
+	}
+	for _, test := range cases {
+		m, err := ParseGTPVertex(test.vertex)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected %s to be a bad vertex", test.vertex)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.vertex, err.Error())
+			continue
+		}
+		if *m != *test.move {
+			t.Errorf("expected %s to be %v but got %v", test.vertex, test.move, m)
+		}
+		if m.Pass {
+			continue
+		}
+		vertex := GTPVertex(m.Coords[0], m.Coords[1])
+		if vertex != strings.ToUpper(test.vertex) {
+			t.Errorf("expected %v to be %s but got %s", m, test.vertex, vertex)
+		}
+	}
+}
+
+func TestGTPClient(t *testing.T) {
+	c := startStubEngine(t)
+	game := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+	}
+	play := func(m *Move) {
+		if err := game.Move(m); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	// the engine is set up and told about the moves so far
+	play(&Move{Coords: Coords{0, 0}})
+	m, err := c.GenMove(game.Position())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if *m != (Move{Coords: Coords{0, 1}}) {
+		t.Errorf("expected the engine to play B1 but got %v", m)
+	}
+	play(m)
+	// then only told about new moves
+	play(&Move{Coords: Coords{5, 5}})
+	m, err = c.GenMove(game.Position())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if *m != (Move{Coords: Coords{0, 2}}) {
+		t.Errorf("expected the engine to play C1 but got %v", m)
+	}
+	history, err := c.Command("history")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := "boardsize clear_board komi play genmove play genmove history"
+	if history != expected {
+		t.Errorf("expected commands %q but got %q", expected, history)
+	}
+
+	// another game starts over
+	other := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+	}
+	m, err = c.GenMove(other.Position())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if *m != (Move{Coords: Coords{0, 0}}) {
+		t.Errorf("expected the engine to play A1 but got %v", m)
+	}
+
+	if _, err = c.Command("bogus"); err == nil {
+		t.Errorf("expected unknown command to fail")
+	}
+	if err = c.Close(); err != nil {
+		t.Errorf(err.Error())
+	}
+}
+
+// fakeEngine plays the given moves in order
+type fakeEngine struct {
+	moves []*Move
+}
+
+func (e *fakeEngine) GenMove(history History, next Stone) (*Move, error) {
+	if len(e.moves) == 0 {
+		return nil, fmt.Errorf("out of moves")
+	}
+	m := e.moves[0]
+	e.moves = e.moves[1:]
+	return m, nil
+}
+
+func TestServerEngine(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	if err := server.Handle("start U1 engine", "U1", "C1"); err == nil {
+		t.Errorf("expected starting without an engine to fail")
+	}
+	engine := &fakeEngine{moves: []*Move{
+		{Coords: Coords{3, 3}}, {Coords: Coords{15, 15}},
+	}}
+	server.Engine = engine
+
+	commands := []struct {
+		input  string
+		player string
+		moves  int
+		// engine is what the engine says after the reply, if it moves
+		engine string
+		err    bool
+	}{
+		// the engine moves first as black
+		{"start engine U1", "U1", 1, "engine played D4", false},
+		{"move 1 D5", "U1", 3, "engine played P16", false},
+		// the engine is out of moves, so it says so and is asked again
+		{"move 1 E5", "U1", 4, "engine could not move", false},
+		{"play 1", "U1", 4, "engine could not move", false},
+		{"move 1 E6", "U1", 4, "", true},
+	}
+	for _, c := range commands {
+		err := server.Handle(c.input, c.player, "C1")
+		if err == nil && c.err {
+			t.Errorf("expected %s to fail", c.input)
+		} else if err != nil && !c.err {
+			t.Fatalf("%s: %s", c.input, err.Error())
+		}
+		if !c.err {
+			<-server.Replies
+		}
+		if c.engine != "" {
+			r := <-server.Replies
+			if !strings.Contains(r.Details+r.Text, c.engine) {
+				t.Errorf("after %s expected %q but got %q %q", c.input,
+					c.engine, r.Details, r.Text)
+			}
+		}
+		sess, _ := server.Get(1)
+		sess.Lock()
+		moves := sess.Game.Summary().Moves
+		sess.Unlock()
+		if moves != c.moves {
+			t.Errorf("after %s expected %d moves but got %d", c.input, c.moves,
+				moves)
+		}
+	}
+	engine.moves = []*Move{{Pass: true}}
+	if err := server.Handle("play 1", "U1", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	if r := <-server.Replies; r.Details != "engine passed" {
+		t.Errorf("expected the engine to pass but got %q", r.Details)
+	}
+	events, _ := server.Store.Events(1, 1)
+	if len(events) != 1 || events[0].Player != EngineID ||
+		events[0].Kind != PassEvent {
+		t.Errorf("expected the engine to pass but got %v", events)
+	}
+}
+
+// slowEngine waits to be let go before it moves
+type slowEngine struct {
+	release chan struct{}
+}
+
+func (e *slowEngine) GenMove(history History, next Stone) (*Move, error) {
+	<-e.release
+	return &Move{Coords: Coords{3, 3}}, nil
+}
+
+func TestServerEngineLater(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	engine := &slowEngine{release: make(chan struct{})}
+	server.Engine = engine
+
+	if err := server.Handle("start engine U1", "U1", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	// the game can be used while the engine is thinking
+	if err := server.Handle("show 1", "U1", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	close(engine.release)
+	if r := <-server.Replies; r.Details != "engine played D4" {
+		t.Errorf("expected the engine to move but got %q %q", r.Details, r.Text)
+	}
+}
@@ -0,0 +1,289 @@
+package gobot
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// GTPProtocolVersion is the version of the Go Text Protocol the GTP server
+// speaks
+const GTPProtocolVersion = "2"
+
+// gtpCommands are the commands the GTP server knows, in the order they are
+// listed
+var gtpCommands = []string{
+	"protocol_version", "name", "version", "known_command", "list_commands",
+	"boardsize", "clear_board", "komi", "play", "genmove", "undo",
+	"showboard", "final_score", "final_status_list", "quit",
+}
+
+// errQuit ends a GTP session after the quit command is answered
+var errQuit = errors.New("quit")
+
+// GTPServer plays a game with a controller, such as a GUI or another engine,
+// speaking the Go Text Protocol. Moves are picked by an Engine.
+type GTPServer struct {
+	Engine Engine
+	Komi   float64
+	game   *State
+	// the games before each move, for undo
+	undo []*State
+}
+
+// NewGTPServer creates a GTP server that plays moves picked by an engine on
+// an empty 19x19 board
+func NewGTPServer(e Engine) *GTPServer {
+	s := &GTPServer{Engine: e, Komi: Komi}
+	s.clear(19)
+	return s
+}
+
+// Serve reads commands from r and writes responses to w until the quit
+// command or the end of the input
+func (s *GTPServer) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		id := ""
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			id, fields = fields[0], fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		response, err := s.Command(fields[0], fields[1:]...)
+		status := "="
+		if err != nil && err != errQuit {
+			status, response = "?", err.Error()
+		}
+		_, werr := fmt.Fprintf(w, "%s%s %s\n\n", status, id, response)
+		if werr != nil {
+			return werr
+		}
+		if err == errQuit {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Command runs one GTP command and returns the response
+func (s *GTPServer) Command(name string, args ...string) (string, error) {
+	switch name {
+	case "protocol_version":
+		return GTPProtocolVersion, nil
+	case "name":
+		return "gobot", nil
+	case "version":
+		return "", nil
+	case "known_command":
+		if len(args) != 1 {
+			return "", errors.New("syntax error")
+		}
+		return strconv.FormatBool(contains(gtpCommands, args[0])), nil
+	case "list_commands":
+		return strings.Join(gtpCommands, "\n"), nil
+	case "boardsize":
+		return "", s.boardsize(args)
+	case "clear_board":
+		s.clear(s.game.Board().Height())
+		return "", nil
+	case "komi":
+		if len(args) != 1 {
+			return "", errors.New("syntax error")
+		}
+		komi, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return "", errors.New("syntax error")
+		}
+		s.Komi = komi
+		if k, ok := s.Engine.(KomiSetter); ok {
+			k.SetKomi(komi)
+		}
+		return "", nil
+	case "play":
+		return "", s.play(args)
+	case "genmove":
+		return s.genmove(args)
+	case "undo":
+		if len(s.undo) == 0 {
+			return "", errors.New("cannot undo")
+		}
+		s.game = s.undo[len(s.undo)-1]
+		s.undo = s.undo[:len(s.undo)-1]
+		return "", nil
+	case "showboard":
+		return s.showboard(), nil
+	case "final_score":
+		board := s.game.Board()
+		return board.RemoveStones(board.DeadStones()).Result(s.Komi), nil
+	case "final_status_list":
+		return s.finalStatusList(args)
+	case "quit":
+		return "", errQuit
+	}
+	return "", errors.New("unknown command")
+}
+
+func (s *GTPServer) boardsize(args []string) error {
+	if len(args) != 1 {
+		return errors.New("syntax error")
+	}
+	size, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errors.New("syntax error")
+	}
+	if size < 2 || size > 19 {
+		return errors.New("unacceptable size")
+	}
+	s.clear(size)
+	return nil
+}
+
+// clear starts over on an empty board of a size
+func (s *GTPServer) clear(size int) {
+	s.game = &State{
+		History: History([]Board{NewBoard(size)}),
+		Next:    BlackStone,
+	}
+	s.undo = nil
+}
+
+func (s *GTPServer) play(args []string) error {
+	if len(args) != 2 {
+		return errors.New("syntax error")
+	}
+	stone, err := parseGTPColor(args[0])
+	if err != nil {
+		return err
+	}
+	m, err := ParseGTPVertex(args[1])
+	if err != nil {
+		return errors.New("syntax error")
+	}
+	return s.move(m, stone)
+}
+
+func (s *GTPServer) genmove(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("syntax error")
+	}
+	stone, err := parseGTPColor(args[0])
+	if err != nil {
+		return "", err
+	}
+	history, _ := s.game.Position()
+	m, err := s.Engine.GenMove(history, stone)
+	if err != nil {
+		return "", err
+	}
+	err = s.move(m, stone)
+	if err != nil {
+		return "", err
+	}
+	if m.Pass {
+		return "pass", nil
+	}
+	return GTPVertex(m.Coords[0], m.Coords[1]), nil
+}
+
+// move plays a stone of either color, since GTP does not require players to
+// take turns
+func (s *GTPServer) move(m *Move, stone Stone) error {
+	g := &State{
+		History:  append(History{}, s.game.History...),
+		Next:     stone,
+		Captures: s.game.Captures,
+		Passes:   s.game.Passes,
+	}
+	if !g.Validate(m) {
+		return errors.New("illegal move")
+	}
+	err := g.Move(m)
+	if err != nil {
+		return err
+	}
+	s.undo = append(s.undo, s.game)
+	s.game = g
+	return nil
+}
+
+// showboard draws the board with GTP coordinates, starting on a new line
+// after the status
+func (s *GTPServer) showboard() string {
+	board := s.game.Board()
+	size := board.Height()
+	header := "  "
+	for y := 0; y < size; y++ {
+		header += " " + string(gtpColumns[y])
+	}
+	lines := []string{"", header}
+	for x := size - 1; x >= 0; x-- {
+		line := fmt.Sprintf("%2d", x+1)
+		for y := 0; y < size; y++ {
+			letter := stoneLetters[board.Get(x, y)]
+			if letter == 'B' {
+				letter = 'X'
+			} else if letter == 'W' {
+				letter = 'O'
+			}
+			line += " " + string(letter)
+		}
+		lines = append(lines, fmt.Sprintf("%s %2d", line, x+1))
+	}
+	lines = append(lines, header)
+	return strings.Join(lines, "\n")
+}
+
+// finalStatusList lists the vertices of the stones that are dead, in seki or
+// alive otherwise, as suggested by DeadStones and Seki
+func (s *GTPServer) finalStatusList(args []string) (string, error) {
+	if len(args) != 1 || !contains([]string{"alive", "dead", "seki"}, args[0]) {
+		return "", errors.New("syntax error")
+	}
+	board := s.game.Board()
+	status := map[Coords]string{}
+	for _, c := range board.DeadStones() {
+		status[c] = "dead"
+	}
+	for _, c := range board.Seki() {
+		status[c] = "seki"
+	}
+	vertices := []string{}
+	for y := range board {
+		for x := range board[y] {
+			if board.Get(x, y) == EmptyStone {
+				continue
+			}
+			st, ok := status[Coords{x, y}]
+			if !ok {
+				st = "alive"
+			}
+			if st == args[0] {
+				vertices = append(vertices, GTPVertex(x, y))
+			}
+		}
+	}
+	return strings.Join(vertices, " "), nil
+}
+
+func parseGTPColor(color string) (Stone, error) {
+	switch strings.ToLower(color) {
+	case "b", "black":
+		return BlackStone, nil
+	case "w", "white":
+		return WhiteStone, nil
+	}
+	return EmptyStone, errors.New("syntax error")
+}
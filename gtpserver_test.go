@@ -0,0 +1,142 @@
+package gobot_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestGTPServer(t *testing.T) {
+	engine := &fakeEngine{moves: []*Move{
+		{Coords: Coords{1, 1}}, {Coords: Coords{0, 2}}, {Pass: true},
+	}}
+	s := NewGTPServer(engine)
+	cases := []struct {
+		command  string
+		response string
+	}{
+		{"protocol_version", "= 2"},
+		{"1 known_command genmove", "=1 true"},
+		{"2 known_command bogus", "=2 false"},
+		{"bogus", "? unknown command"},
+		{"boardsize 20", "? unacceptable size"},
+		{"boardsize 3 # comments are ignored", "= "},
+		{"komi 0.5", "= "},
+		{"play b A3", "= "},
+		{"play w A3", "? illegal move"},
+		{"play w D1", "? illegal move"},
+		{"play red C1", "? syntax error"},
+		// players do not have to take turns
+		{"play b C1", "= "},
+		{"genmove w", "= B2"},
+		{"genmove w", "? illegal move"},
+		{"undo", "= "},
+		{"genmove b", "= pass"},
+		{"showboard", "= \n   A B C\n 3 X . .  3\n 2 . . .  2\n" +
+			" 1 . . X  1\n   A B C"},
+		{"final_score", "= B+8.5"},
+		{"undo", "= "},
+		{"undo", "= "},
+		{"undo", "= "},
+		{"undo", "? cannot undo"},
+		{"final_score", "= W+0.5"},
+		{"quit", "= "},
+		{"protocol_version", ""},
+	}
+	input := []string{}
+	expected := ""
+	for _, c := range cases {
+		input = append(input, c.command)
+		if c.response != "" {
+			expected += c.response + "\n\n"
+		}
+	}
+	output := &bytes.Buffer{}
+	err := s.Serve(strings.NewReader(strings.Join(input, "\n")), output)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if output.String() != expected {
+		t.Errorf("expected responses\n%s\nbut got\n%s", expected, output)
+	}
+}
+
+func TestGTPServerKomi(t *testing.T) {
+	engine := NewMCTS(NewRNG(1))
+	s := NewGTPServer(engine)
+	if _, err := s.Command("komi", "0.5"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if s.Komi != 0.5 || engine.Komi != 0.5 {
+		t.Errorf("expected komi 0.5 but got %v and %v", s.Komi, engine.Komi)
+	}
+}
+
+func TestGTPServerFinalStatus(t *testing.T) {
+	s := NewGTPServer(&RandomEngine{RNG: NewRNG(1)})
+	if _, err := s.Command("boardsize", "7"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	b := compactBoard(
+		t, "..BW.../..BW.../..BW.../..BW.B./..BW.../..BW.../..BW...",
+	)
+	color := map[Stone]string{BlackStone: "b", WhiteStone: "w"}
+	for y := range b {
+		for x := range b[y] {
+			if b.Get(x, y) == EmptyStone {
+				continue
+			}
+			_, err := s.Command("play", color[b.Get(x, y)], GTPVertex(x, y))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+		}
+	}
+	cases := []struct {
+		command  string
+		args     []string
+		response string
+		err      bool
+	}{
+		{"final_status_list", []string{"dead"}, "D6", false},
+		{"final_status_list", []string{"seki"}, "", false},
+		{"final_status_list", []string{"alive"}, "A3 A4 B3 B4 C3 C4 D3 D4 E3 " +
+			"E4 F3 F4 G3 G4", false},
+		{"final_status_list", []string{"bogus"}, "", true},
+		{"final_score", nil, "W+13.5", false},
+	}
+	for _, c := range cases {
+		response, err := s.Command(c.command, c.args...)
+		if err != nil && !c.err {
+			t.Errorf("%s: unexpected error %s", c.command, err.Error())
+		} else if err == nil && c.err {
+			t.Errorf("%s: expected an error", c.command)
+		} else if response != c.response {
+			t.Errorf("%s: expected %q but got %q", c.command, c.response,
+				response)
+		}
+	}
+}
+
+func TestRandomEngine(t *testing.T) {
+	e := RandomEngine{RNG: NewRNG(1)}
+	board := compactBoard(t, ".B../BB../..../....")
+	for i := 0; i < 20; i++ {
+		m, err := e.GenMove(History{board}, BlackStone)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if m.Pass || board.Get(m.Coords[0], m.Coords[1]) != EmptyStone {
+			t.Fatalf("expected black to play on an empty point but got %v", m)
+		}
+		if m.Coords[0] == 0 && m.Coords[1] == 0 {
+			t.Fatalf("expected black not to fill its eye")
+		}
+	}
+	m, _ := e.GenMove(History{compactBoard(t, ".B/BB")}, BlackStone)
+	if !m.Pass {
+		t.Errorf("expected black to pass rather than fill an eye but got %v", m)
+	}
+}
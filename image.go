@@ -60,15 +60,51 @@ func (c *Circle) At(x, y int) color.Color {
 	return color.Alpha{0}
 }
 
+// TerritorySize is how big the square marking a point's owner is in pixels
+const TerritorySize = StoneSize / 3
+
+// GlyphScale is how many pixels wide each dot of a marker letter is
+const GlyphScale = 4
+
+// MarkerColor is the color of the disc behind a marker letter
+var MarkerColor = color.RGBA{30, 90, 200, 255}
+
+// glyphs are the dots of the marker letters, 5 wide and 7 high
+var glyphs = map[byte][7]string{
+	'A': {" ### ", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'B': {"#### ", "#   #", "#   #", "#### ", "#   #", "#   #", "#### "},
+	'C': {" ### ", "#   #", "#    ", "#    ", "#    ", "#   #", " ### "},
+	'D': {"#### ", "#   #", "#   #", "#   #", "#   #", "#   #", "#### "},
+	'E': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#####"},
+}
+
+// center of the point at (x, y) in the board image
+func center(x, y int) image.Point {
+	return image.Point{
+		BoardPadding + StoneSize*x + 2*StoneSpacing*x,
+		BoardPadding + StoneSize*y + 2*StoneSpacing*y,
+	}
+}
+
+// Overlay is drawn on top of the stones
+type Overlay struct {
+	Markers []Marker
+	// Territory shades the points each color owns, indexed like the board
+	Territory [][]Stone
+}
+
 // Render a board into an image
 func Render(board Board) (image.Image, error) {
+	return RenderOverlay(board, Overlay{})
+}
+
+// RenderOverlay renders a board into an image with an overlay on top
+func RenderOverlay(board Board, o Overlay) (image.Image, error) {
 	im := image.NewRGBA(boardImage.Bounds())
 	draw.Draw(im, im.Bounds(), boardImage, image.ZP, draw.Src)
 	for i, row := range board {
 		for j, stone := range row {
-			x := BoardPadding + StoneSize*j + 2*StoneSpacing*j
-			y := BoardPadding + StoneSize*i + 2*StoneSpacing*i
-			p := image.Point{x, y}
+			p := center(j, i)
 			r := StoneSize / 2
 			var src image.Image
 			if stone == WhiteStone {
@@ -89,5 +125,52 @@ func Render(board Board) (image.Image, error) {
 			)
 		}
 	}
+	for y, row := range o.Territory {
+		for x, owner := range row {
+			if owner != EmptyStone && board.Get(x, y) != owner {
+				drawTerritory(im, center(x, y), owner)
+			}
+		}
+	}
+	for _, m := range o.Markers {
+		drawMarker(im, center(m.Coords[0], m.Coords[1]), m.Label)
+	}
 	return im, nil
 }
+
+// drawTerritory draws a small square of the owner's color on a point
+func drawTerritory(im draw.Image, p image.Point, owner Stone) {
+	src := image.Black
+	if owner == WhiteStone {
+		src = image.White
+	}
+	r := image.Rect(-TerritorySize/2, -TerritorySize/2, TerritorySize/2,
+		TerritorySize/2).Add(p)
+	draw.Draw(im, r, src, image.ZP, draw.Src)
+}
+
+// drawMarker draws a letter on a disc centered at a point
+func drawMarker(im draw.Image, p image.Point, label byte) {
+	disc := &Circle{p, StoneSize / 2}
+	draw.DrawMask(
+		im, im.Bounds(), image.NewUniform(MarkerColor), image.ZP, disc,
+		image.ZP, draw.Over,
+	)
+	glyph, ok := glyphs[label]
+	if !ok {
+		return
+	}
+	left := p.X - 5*GlyphScale/2
+	top := p.Y - 7*GlyphScale/2
+	for row, dots := range glyph {
+		for col, dot := range dots {
+			if dot == ' ' {
+				continue
+			}
+			r := image.Rect(0, 0, GlyphScale, GlyphScale).Add(image.Point{
+				left + col*GlyphScale, top + row*GlyphScale,
+			})
+			draw.Draw(im, r, image.White, image.ZP, draw.Src)
+		}
+	}
+}
@@ -4,7 +4,9 @@ package gobot
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -37,9 +39,7 @@ type Votable interface {
 	// Vote for a move
 	Vote(*Move) error
 	// Schedule starts a vote timer, and resets any existing timer
-	Schedule() *time.Timer
-	// Block until the vote timer is up
-	Block()
+	Schedule() Timer
 	// Random picks random vote
 	Random() (*Move, error)
 	// Empty returns true if no votes have been cast
@@ -48,6 +48,12 @@ type Votable interface {
 	Reset() error
 	// Whether or not voting is required
 	Required() bool
+	// Rules returns the voting rules
+	Rules() Voting
+	// SetRules changes the voting rules
+	SetRules(Voting) error
+	// Use a clock for vote timers and a random source for picking votes
+	Use(Clock, RNG)
 }
 
 // Storable implements something that can be serialized and loaded by ID
@@ -58,6 +64,89 @@ type Storable interface {
 	Load([]byte) error
 	// Serialize this game to a byte array
 	Save() ([]byte, error)
+	// Return the version this storable was loaded at
+	Version() int64
+	// Change the version after saving
+	SetVersion(int64)
+	// Describe the storable so it can be queried without loading it
+	Metadata() Metadata
+	// Record an event to be saved along with the storable
+	Record(Event)
+	// Return the events recorded since the last call and forget them
+	TakeEvents() []Event
+	// Return true if events were recorded that have not been taken yet
+	Unsaved() bool
+}
+
+// Metadata describes a game so stores can index it
+type Metadata struct {
+	Finished  bool
+	Archived  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Channel   string
+	Players   []string
+	// Waiting are the players who can make the next move in a two player
+	// game that is not finished
+	Waiting []string
+}
+
+// PlayerList joins the unique players with spaces, padded with a space on
+// both ends so a player can be matched with LIKE '% player %'.
+func (m Metadata) PlayerList() string {
+	return playerList(m.Players)
+}
+
+// WaitingList joins the waiting players like PlayerList
+func (m Metadata) WaitingList() string {
+	return playerList(m.Waiting)
+}
+
+func playerList(list []string) string {
+	seen := map[string]bool{}
+	players := []string{}
+	for _, p := range list {
+		if !seen[p] {
+			seen[p] = true
+			players = append(players, p)
+		}
+	}
+	if len(players) == 0 {
+		return ""
+	}
+	sort.Strings(players)
+	return " " + strings.Join(players, " ") + " "
+}
+
+// Filter picks the games a store searches for
+type Filter struct {
+	// All includes finished games
+	All bool
+	// Archived searches the archived games instead
+	Archived bool
+	// Player only matches the games this player is named in
+	Player string
+	// Waiting only matches the games where this player has the next move
+	Waiting string
+	// Offset skips the first games found
+	Offset int
+	// Limit is how many games to return at most, or all if not positive
+	Limit int
+}
+
+// Match checks if a game described by its metadata passes the filter
+func (f Filter) Match(m Metadata) bool {
+	switch {
+	case m.Archived != f.Archived:
+		return false
+	case m.Finished && !f.All && !f.Archived:
+		return false
+	case f.Player != "" && !contains(m.Players, f.Player):
+		return false
+	case f.Waiting != "" && !contains(m.Waiting, f.Waiting):
+		return false
+	}
+	return true
 }
 
 // Playable implements something that players play
@@ -66,6 +155,8 @@ type Playable interface {
 	IsPlaying(playerID string) bool
 	// Check if a player can make the next move
 	CanMove(playerID string) bool
+	// The channel the game is played in, if known
+	Channel() string
 }
 
 // A Game interface for a game
@@ -74,8 +165,18 @@ type Game interface {
 	Board() Board
 	// Check if the game is finished
 	Finished() bool
+	// Check if the game was archived
+	Archived() bool
+	// Archive the game so it is no longer listed or played
+	Archive()
+	// Summarize the game for the game list
+	Summary() Summary
+	// Return the boards played so far and the color to play next
+	Position() (History, Stone)
 	// Play a move
 	Move(*Move) error
+	// Resign the game for the color to play next
+	Resign() error
 	// Whether or not a move is valid to play next
 	Validate(*Move) bool
 }
@@ -92,8 +193,28 @@ type Store interface {
 	New(Blueprint) (*Session, error)
 	// Return the last session played
 	Last() (*Session, error)
+	// Return the session that was started last of the finished sessions
+	// that are not archived
+	LastFinished() (*Session, error)
 	// Save a storable to storage
 	Save(Storable) error
-	// List active sessions, optionally listing all sessions
+	// List active sessions, optionally listing all sessions that are not
+	// archived
 	List(all bool) ([]*Session, error)
+	// List archived sessions
+	ListArchived() ([]*Session, error)
+	// Search for the sessions matching a filter, most recently updated
+	// first, and count how many match in all
+	Search(Filter) ([]*Session, int, error)
+	// Archive a game so it is no longer listed
+	Archive(id int64) error
+	// Delete a game and its events for good
+	Delete(id int64) error
+	// Return the last events of a game, oldest first, or all of them if
+	// limit is not positive
+	Events(id int64, limit int) ([]Event, error)
+	// Restore an archived game, keeping its id
+	Restore(ArchivedGame) error
+	// Use a clock and random source for the sessions it creates
+	Use(Clock, RNG)
 }
@@ -0,0 +1,445 @@
+package gobot
+
+import "sort"
+
+// adjacent are the four points next to a point, some of which may be off the
+// board
+func adjacent(c Coords) [4]Coords {
+	x, y := c[0], c[1]
+	return [4]Coords{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}}
+}
+
+// flood finds the points connected to (x, y) through points that match
+func (b Board) flood(x, y int, match func(Stone) bool) []Coords {
+	points := []Coords{{x, y}}
+	seen := map[Coords]bool{{x, y}: true}
+	for i := 0; i < len(points); i++ {
+		for _, n := range adjacent(points[i]) {
+			if !seen[n] && match(b.Get(n[0], n[1])) {
+				seen[n] = true
+				points = append(points, n)
+			}
+		}
+	}
+	return points
+}
+
+// libertyPoints are the empty points next to the stone at (x, y) and its
+// connected stones
+func (b Board) libertyPoints(x, y int) []Coords {
+	liberties := []Coords{}
+	seen := map[Coords]bool{}
+	for c := range b.group(x, y) {
+		for _, n := range adjacent(c) {
+			if !seen[n] && b.Get(n[0], n[1]) == EmptyStone {
+				seen[n] = true
+				liberties = append(liberties, n)
+			}
+		}
+	}
+	return liberties
+}
+
+// TrueEye checks if an empty point is an eye of a color that cannot be
+// destroyed. All of its neighbors must be stones of that color, and the
+// opponent may hold at most one of its diagonal points, or none on the edge.
+func (b Board) TrueEye(x, y int, stone Stone) bool {
+	if b.Get(x, y) != EmptyStone || !b.eye(x, y, stone) {
+		return false
+	}
+	edge, opponent := false, 0
+	for _, d := range [][2]int{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+		switch b.Get(x+d[0], y+d[1]) {
+		case BoundaryStone:
+			edge = true
+		case stone.Opponent():
+			opponent++
+		}
+	}
+	if edge {
+		return opponent == 0
+	}
+	return opponent < 2
+}
+
+// benson is the result of Benson's algorithm for one color
+type benson struct {
+	// alive are the stones that can never be captured
+	alive map[Coords]bool
+	// enclosed are the points of the regions that are all liberties of alive
+	// stones
+	enclosed map[Coords]bool
+}
+
+// benson finds the stones of a color that are unconditionally alive with
+// Benson's algorithm: the groups that each have two regions all of whose
+// empty points are liberties of the group, where the regions are surrounded
+// only by such groups.
+func (b Board) benson(stone Stone) benson {
+	chainOf := map[Coords]int{}
+	regionOf := map[Coords]int{}
+	chains, regions := [][]Coords{}, [][]Coords{}
+	for y := range b {
+		for x := range b[y] {
+			c := Coords{x, y}
+			if _, ok := chainOf[c]; ok {
+				continue
+			}
+			if _, ok := regionOf[c]; ok {
+				continue
+			}
+			if b.Get(x, y) == stone {
+				chain := b.flood(x, y, func(s Stone) bool { return s == stone })
+				for _, p := range chain {
+					chainOf[p] = len(chains)
+				}
+				chains = append(chains, chain)
+			} else {
+				region := b.flood(x, y, func(s Stone) bool {
+					return s != stone && s != BoundaryStone
+				})
+				for _, p := range region {
+					regionOf[p] = len(regions)
+				}
+				regions = append(regions, region)
+			}
+		}
+	}
+	// borders are the chains next to each region, and vital the chains that
+	// every empty point of the region is a liberty of
+	borders := make([]map[int]bool, len(regions))
+	vital := make([]map[int]bool, len(regions))
+	for r, region := range regions {
+		borders[r] = map[int]bool{}
+		for _, p := range region {
+			for _, n := range adjacent(p) {
+				if chain, ok := chainOf[n]; ok {
+					borders[r][chain] = true
+				}
+			}
+		}
+		vital[r] = map[int]bool{}
+		for chain := range borders[r] {
+			vital[r][chain] = true
+		}
+		for _, p := range region {
+			if b.Get(p[0], p[1]) != EmptyStone {
+				continue
+			}
+			next := map[int]bool{}
+			for _, n := range adjacent(p) {
+				if chain, ok := chainOf[n]; ok && vital[r][chain] {
+					next[chain] = true
+				}
+			}
+			vital[r] = next
+		}
+	}
+	alive := make([]bool, len(chains))
+	for i := range alive {
+		alive[i] = true
+	}
+	healthy := make([]bool, len(regions))
+	for i := range healthy {
+		healthy[i] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for chain := range chains {
+			if !alive[chain] {
+				continue
+			}
+			count := 0
+			for r := range regions {
+				if healthy[r] && vital[r][chain] {
+					count++
+				}
+			}
+			if count < 2 {
+				alive[chain], changed = false, true
+			}
+		}
+		for r := range regions {
+			if !healthy[r] {
+				continue
+			}
+			for chain := range borders[r] {
+				if !alive[chain] {
+					healthy[r], changed = false, true
+					break
+				}
+			}
+		}
+	}
+	result := benson{alive: map[Coords]bool{}, enclosed: map[Coords]bool{}}
+	for chain, points := range chains {
+		if alive[chain] {
+			for _, p := range points {
+				result.alive[p] = true
+			}
+		}
+	}
+	for r, points := range regions {
+		// the opponent may still live in a big region, but not in one that
+		// is all liberties of an alive group
+		for chain := range vital[r] {
+			if healthy[r] && alive[chain] {
+				for _, p := range points {
+					result.enclosed[p] = true
+				}
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Alive checks if the stone at (x, y) is unconditionally alive, so it can
+// never be captured however many moves the opponent makes in a row
+func (b Board) Alive(x, y int) bool {
+	stone := b.Get(x, y)
+	if stone != BlackStone && stone != WhiteStone {
+		return false
+	}
+	return b.benson(stone).alive[Coords{x, y}]
+}
+
+// Ladder checks if the stone at (x, y) and its connected stones are caught
+// in a ladder, so they are captured by ataris one after another however they
+// run. Stones in atari are read with their owner to move and stones with two
+// liberties with their opponent to move. Ko is not taken into account.
+func (b Board) Ladder(x, y int) bool {
+	stone := b.Get(x, y)
+	if stone != BlackStone && stone != WhiteStone {
+		return false
+	}
+	depth := b.Width() * b.Height()
+	switch b.Liberties(x, y) {
+	case 1:
+		return b.caught(x, y, depth)
+	case 2:
+		return b.chase(x, y, depth)
+	}
+	return false
+}
+
+// chase checks if the opponent to move can put the stones at (x, y), which
+// have two liberties, in an atari they cannot escape
+func (b Board) chase(x, y, depth int) bool {
+	attacker := b.Get(x, y).Opponent()
+	for _, l := range b.libertyPoints(x, y) {
+		after, _, err := b.Play(l[0], l[1], attacker)
+		if err != nil {
+			continue
+		}
+		if after.Liberties(x, y) == 1 && after.caught(x, y, depth-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// caught checks if the stones at (x, y), which are in atari with their owner
+// to move, are captured whether they run or capture stones around them
+func (b Board) caught(x, y, depth int) bool {
+	if depth <= 0 {
+		// a ladder this long must be going in circles, so give up on it
+		return false
+	}
+	stone := b.Get(x, y)
+	moves := b.libertyPoints(x, y)
+	for c := range b.group(x, y) {
+		for _, n := range adjacent(c) {
+			if b.Get(n[0], n[1]) == stone.Opponent() &&
+				b.Liberties(n[0], n[1]) == 1 {
+				moves = append(moves, b.libertyPoints(n[0], n[1])...)
+			}
+		}
+	}
+	for _, m := range moves {
+		after, _, err := b.Play(m[0], m[1], stone)
+		if err != nil {
+			continue
+		}
+		switch liberties := after.Liberties(x, y); {
+		case liberties >= 3:
+			return false
+		case liberties == 2 && !after.chase(x, y, depth):
+			return false
+		}
+	}
+	return true
+}
+
+// DeadStones suggests which stones are dead at the end of a game: the groups
+// that are not unconditionally alive or in seki, have fewer than two true
+// eyes and no other area of their own, and stand where the opponent would own
+// the area without them. Groups that share an eye live or die together.
+func (b Board) DeadStones() []Coords {
+	dead := []Coords{}
+	alive := map[Stone]map[Coords]bool{
+		BlackStone: b.benson(BlackStone).alive,
+		WhiteStone: b.benson(WhiteStone).alive,
+	}
+	seki, _ := b.seki()
+	// stones found dead are taken off before looking again, smallest groups
+	// first, so a dead stone does not take the area of the group around it
+	board := b
+	for found := true; found; {
+		found = false
+		for _, group := range board.units() {
+			c := group[0]
+			stone := board.Get(c[0], c[1])
+			if alive[stone][c] || seki[c] || board.settled(group, stone) {
+				continue
+			}
+			without := board.RemoveStones(group)
+			own, opponent := owners(
+				without.Influence(EstimateDilations, EstimateErosions), group,
+				stone,
+			)
+			if opponent > own {
+				dead = append(dead, group...)
+				board, found = without, true
+				break
+			}
+		}
+	}
+	return dead
+}
+
+// groups lists the groups of stones on the board, smallest first
+func (b Board) groups() [][]Coords {
+	groups := [][]Coords{}
+	seen := map[Coords]bool{}
+	for y := range b {
+		for x := range b[y] {
+			stone := b.Get(x, y)
+			if stone == EmptyStone || seen[Coords{x, y}] {
+				continue
+			}
+			group := b.flood(x, y, func(s Stone) bool { return s == stone })
+			for _, p := range group {
+				seen[p] = true
+			}
+			groups = append(groups, group)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i]) < len(groups[j])
+	})
+	return groups
+}
+
+// units lists the groups of stones on the board like groups, but counts
+// groups that share an eye as one, since filling the eye takes a liberty
+// from each of them
+func (b Board) units() [][]Coords {
+	units := [][]Coords{}
+	seen := map[Coords]bool{}
+	for y := range b {
+		for x := range b[y] {
+			stone := b.Get(x, y)
+			if stone == EmptyStone || seen[Coords{x, y}] {
+				continue
+			}
+			unit := []Coords{}
+			points := []Coords{{x, y}}
+			seen[Coords{x, y}] = true
+			for i := 0; i < len(points); i++ {
+				p := points[i]
+				if b.Get(p[0], p[1]) == stone {
+					unit = append(unit, p)
+				}
+				for _, n := range adjacent(p) {
+					switch s := b.Get(n[0], n[1]); {
+					case seen[n]:
+					case s == stone, s == EmptyStone && b.eye(n[0], n[1], stone):
+						seen[n] = true
+						points = append(points, n)
+					}
+				}
+			}
+			units = append(units, unit)
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool {
+		return len(units[i]) < len(units[j])
+	})
+	return units
+}
+
+// settled checks if a group has two true eyes, or an area of empty points
+// that reach no other color besides its eyes
+func (b Board) settled(group []Coords, stone Stone) bool {
+	eyes := 0
+	visited := map[[2]int]bool{}
+	for _, c := range group {
+		for _, n := range adjacent(c) {
+			if b.Get(n[0], n[1]) != EmptyStone || visited[n] {
+				continue
+			}
+			size, owner := b.region(n[0], n[1], visited)
+			if owner != stone {
+				continue
+			}
+			if size > 1 {
+				return true
+			}
+			// a false eye can be filled, so it does not count
+			if b.TrueEye(n[0], n[1], stone) {
+				eyes++
+			}
+		}
+	}
+	return eyes >= 2
+}
+
+// owners counts how many points have influence for a color and how many for
+// its opponent
+func owners(
+	influence [][]int, points []Coords, stone Stone,
+) (own, opponent int) {
+	for _, p := range points {
+		switch v := influence[p[1]][p[0]]; {
+		case v > 0 && stone == BlackStone, v < 0 && stone == WhiteStone:
+			own++
+		case v != 0:
+			opponent++
+		}
+	}
+	return own, opponent
+}
+
+// RemoveStones returns a copy of the board without the stones at some points
+func (b Board) RemoveStones(points []Coords) Board {
+	board := b.Copy()
+	for _, p := range points {
+		board[p[1]][p[0]] = EmptyStone
+	}
+	return board
+}
+
+// hopeless checks if a stone played at (x, y) would obviously lose: it lands
+// in an area that unconditionally alive stones already surround, or it
+// leaves its stones caught in a ladder
+func (b Board) hopeless(x, y int, stone Stone, enclosed map[Coords]bool) bool {
+	if enclosed[Coords{x, y}] {
+		return true
+	}
+	after, _, err := b.Play(x, y, stone)
+	if err != nil {
+		return true
+	}
+	return after.Liberties(x, y) == 2 && after.Ladder(x, y)
+}
+
+// enclosed finds the points surrounded by unconditionally alive stones of
+// either color
+func (b Board) enclosed() map[Coords]bool {
+	enclosed := b.benson(BlackStone).enclosed
+	for p := range b.benson(WhiteStone).enclosed {
+		enclosed[p] = true
+	}
+	return enclosed
+}
@@ -0,0 +1,131 @@
+package gobot_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestTrueEye(t *testing.T) {
+	cases := []struct {
+		board string
+		x, y  int
+		eye   bool
+	}{
+		{".B./BB./...", 0, 0, true},
+		// the opponent holds the only diagonal of a corner eye
+		{".B./BW./...", 0, 0, false},
+		{"WB./B.B/.B.", 1, 1, true},
+		{"WBW/B.B/.B.", 1, 1, false},
+		{"BBB/B.B/BBB", 1, 1, true},
+		{"BBB/..B/BBB", 1, 1, false},
+		{"BBB/B.B/BBB", 0, 0, false},
+	}
+	for _, test := range cases {
+		b := compactBoard(t, test.board)
+		if eye := b.TrueEye(test.x, test.y, BlackStone); eye != test.eye {
+			t.Errorf("expected %d,%d of %s to be an eye: %v", test.x, test.y,
+				test.board, test.eye)
+		}
+	}
+}
+
+func TestAlive(t *testing.T) {
+	cases := []struct {
+		board string
+		x, y  int
+		alive bool
+	}{
+		// both colors have two eyes
+		{".BW.W/BBWWW/.BW../BBWWW/.BW.W", 1, 0, true},
+		{".BW.W/BBWWW/.BW../BBWWW/.BW.W", 2, 0, true},
+		// one eye is not enough
+		{".BW../BBW../BBW../BBW../BBW..", 1, 0, false},
+		// white could fill the big eye and take the rest
+		{"...B./...B./BBBB./...../.....", 0, 2, false},
+		{"...../...../..B../...../.....", 2, 2, false},
+		{"...../...../...../...../.....", 2, 2, false},
+	}
+	for _, test := range cases {
+		b := compactBoard(t, test.board)
+		if alive := b.Alive(test.x, test.y); alive != test.alive {
+			t.Errorf("expected %d,%d of %s to be alive: %v", test.x, test.y,
+				test.board, test.alive)
+		}
+	}
+}
+
+func TestLadder(t *testing.T) {
+	ladder := "........./..B....../.BW....../.B......./........./" +
+		"........./........./........./........."
+	cases := []struct {
+		name   string
+		board  Board
+		caught bool
+	}{
+		{"a ladder", compactBoard(t, ladder), true},
+		{"in atari", compactBoard(t, ladder).Set(3, 2, BlackStone), true},
+		{"a ladder breaker", compactBoard(t, ladder).Set(7, 7, WhiteStone), false},
+		{
+			// the chasing stones at C2 and D2 are in atari themselves
+			"capturing chasing stones",
+			compactBoard(t, ladder).Set(0, 2, WhiteStone).Set(1, 1, WhiteStone).
+				Set(0, 3, WhiteStone).Set(1, 4, WhiteStone),
+			false,
+		},
+		{"three liberties", compactBoard(t, ladder).Set(2, 1, EmptyStone), false},
+	}
+	for _, test := range cases {
+		if caught := test.board.Ladder(2, 2); caught != test.caught {
+			t.Errorf("%s: expected caught to be %v", test.name, test.caught)
+		}
+	}
+}
+
+func TestDeadStones(t *testing.T) {
+	cases := []struct {
+		board string
+		dead  []Coords
+	}{
+		{"B..../...../...../...../....W", []Coords{}},
+		{".BW.W/BBWWW/.BW../BBWWW/.BW.W", []Coords{}},
+		{
+			".BW../BBW../BBW../BBW../BBW..",
+			[]Coords{
+				{0, 1}, {0, 3}, {1, 0}, {1, 1}, {1, 2}, {1, 3}, {1, 4}, {0, 2},
+				{0, 4},
+			},
+		},
+		// a stone inside the opponent's area does not take the area away
+		{
+			"..BW.../..BW.../..BW.../..BW.B./..BW.../..BW.../..BW...",
+			[]Coords{{5, 3}},
+		},
+		{
+			"..BW.../..BW.../.WBW.../..BW.B./..BW.../..BW.W./..BW...",
+			[]Coords{{1, 2}, {5, 3}},
+		},
+		// a false eye does not make a group alive
+		{
+			"......./...B.../..BWB../.BW.WB./..BWB../...B.../.......",
+			[]Coords{{3, 2}, {2, 3}, {4, 3}, {3, 4}},
+		},
+	}
+	for _, test := range cases {
+		dead := compactBoard(t, test.board).DeadStones()
+		sortCoords(dead)
+		sortCoords(test.dead)
+		if !reflect.DeepEqual(dead, test.dead) {
+			t.Errorf("expected %s to have dead stones %v but got %v",
+				test.board, test.dead, dead)
+		}
+	}
+}
+
+func sortCoords(c []Coords) {
+	sort.Slice(c, func(i, j int) bool {
+		return c[i][1] < c[j][1] || c[i][1] == c[j][1] && c[i][0] < c[j][0]
+	})
+}
@@ -0,0 +1,397 @@
+package gobot
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultPlayouts is how many games the MCTS engine plays out per move
+const DefaultPlayouts = 1000
+
+// DefaultThinkTime is the longest the MCTS engine thinks about a move
+const DefaultThinkTime = 10 * time.Second
+
+// mctsExploration weighs trying rarely played moves against playing the
+// moves that won most often
+const mctsExploration = 1.0
+
+// MCTS is an engine that picks moves with Monte Carlo tree search. Each
+// playout finishes the game with random moves that do not fill the player's
+// own eyes and scores it by area. It needs no external program, so it can
+// always play against people and vote in quiet channels.
+type MCTS struct {
+	// Playouts is how many games are played out per move
+	Playouts int
+	// ThinkTime limits how long a move takes, whatever the playouts
+	ThinkTime time.Duration
+	Komi      float64
+	Clock     Clock
+	RNG       RNG
+}
+
+// NewMCTS creates an MCTS engine with the default budget that plays randomly
+// with the given source
+func NewMCTS(rng RNG) *MCTS {
+	return &MCTS{
+		Playouts:  DefaultPlayouts,
+		ThinkTime: DefaultThinkTime,
+		Komi:      Komi,
+		Clock:     SystemClock{},
+		RNG:       rng,
+	}
+}
+
+// SetKomi implements the KomiSetter interface
+func (e *MCTS) SetKomi(komi float64) {
+	e.Komi = komi
+}
+
+// mctsNode is a position in the search tree
+type mctsNode struct {
+	parent *mctsNode
+	// the move that led here and who played it
+	move  *Move
+	mover Stone
+	// the boards played to get here, for ko
+	history  History
+	next     Stone
+	passes   int
+	untried  []*Move
+	children []*mctsNode
+	wins     float64
+	visits   int
+}
+
+// GenMove implements the Engine interface
+func (e *MCTS) GenMove(history History, next Stone) (*Move, error) {
+	root := e.search(history, next)
+	var best *mctsNode
+	for _, child := range root.children {
+		if best == nil || child.visits > best.visits {
+			best = child
+		}
+	}
+	if best == nil {
+		return &Move{Pass: true}, nil
+	}
+	return best.move, nil
+}
+
+// search builds a tree of the moves from a position within the budget
+func (e *MCTS) search(history History, next Stone) *mctsNode {
+	root := &mctsNode{history: history, next: next}
+	// moves from the real game must not repeat any earlier board, and are
+	// worth reading a little further to rule out obviously losing ones
+	g := &State{History: history, Next: next}
+	board := history[len(history)-1]
+	enclosed := board.enclosed()
+	for _, m := range e.candidates(root) {
+		if m.Pass || g.Validate(m) &&
+			!board.hopeless(m.Coords[0], m.Coords[1], next, enclosed) {
+			root.untried = append(root.untried, m)
+		}
+	}
+	deadline := e.Clock.Now().Add(e.ThinkTime)
+	for i := 0; i < e.Playouts; i++ {
+		if e.ThinkTime > 0 && e.Clock.Now().After(deadline) {
+			break
+		}
+		node := e.selectNode(root)
+		node.update(e.playout(node))
+	}
+	return root
+}
+
+// selectNode walks down the tree to the most promising position and expands
+// it with one untried move
+func (e *MCTS) selectNode(node *mctsNode) *mctsNode {
+	for node.passes < 2 {
+		if node.untried == nil {
+			node.untried = e.candidates(node)
+		}
+		for len(node.untried) > 0 {
+			i := e.RNG.Intn(len(node.untried))
+			m := node.untried[i]
+			last := len(node.untried) - 1
+			node.untried[i] = node.untried[last]
+			node.untried = node.untried[:last]
+			if child := node.expand(m); child != nil {
+				return child
+			}
+		}
+		if len(node.children) == 0 {
+			return node
+		}
+		node = node.best()
+	}
+	return node
+}
+
+// candidates are the moves worth trying from a position: every empty point
+// that is not the player's own eye, and passing
+func (e *MCTS) candidates(node *mctsNode) []*Move {
+	board := node.history[len(node.history)-1]
+	moves := []*Move{{Pass: true}}
+	for y := range board {
+		for x := range board[y] {
+			if board.Get(x, y) == EmptyStone && !board.eye(x, y, node.next) {
+				moves = append(moves, &Move{Coords: Coords{x, y}})
+			}
+		}
+	}
+	return moves
+}
+
+// expand adds the position after a move as a child, or returns nil if the
+// move is illegal
+func (n *mctsNode) expand(m *Move) *mctsNode {
+	board := n.history[len(n.history)-1]
+	child := &mctsNode{
+		parent:  n,
+		move:    m,
+		mover:   n.next,
+		history: n.history,
+		next:    n.next.Opponent(),
+	}
+	if m.Pass {
+		child.passes = n.passes + 1
+	} else {
+		next, captures, err := board.Play(m.Coords[0], m.Coords[1], n.next)
+		if err != nil {
+			return nil
+		}
+		// only a capture can bring back an earlier board
+		if captures > 0 && n.history.Ko(next) {
+			return nil
+		}
+		last := len(n.history)
+		child.history = append(n.history[:last:last], next)
+	}
+	n.children = append(n.children, child)
+	return child
+}
+
+// best is the child with the highest upper confidence bound
+func (n *mctsNode) best() *mctsNode {
+	var best *mctsNode
+	bound := math.Inf(-1)
+	logVisits := math.Log(float64(n.visits))
+	for _, child := range n.children {
+		b := math.Inf(1)
+		if child.visits > 0 {
+			b = child.wins/float64(child.visits) +
+				mctsExploration*math.Sqrt(logVisits/float64(child.visits))
+		}
+		if b > bound {
+			best, bound = child, b
+		}
+	}
+	return best
+}
+
+// update counts a playout won by a color in a node and the nodes above it
+func (n *mctsNode) update(winner Stone) {
+	for ; n != nil; n = n.parent {
+		n.visits++
+		if n.mover == winner {
+			n.wins++
+		}
+	}
+}
+
+// playout finishes the game from a node with random moves and returns the
+// winner
+func (e *MCTS) playout(node *mctsNode) Stone {
+	b := newPlayoutBoard(node.history[len(node.history)-1])
+	next := node.next
+	passes := node.passes
+	// games can go on forever with superko, so stop them eventually
+	for i := 0; i < 3*len(b.stones) && passes < 2; i++ {
+		// look for a move from a random point on, which is much quicker than
+		// listing every move and nearly as random
+		start := e.RNG.Intn(len(b.stones))
+		moved := false
+		for j := range b.stones {
+			p := (start + j) % len(b.stones)
+			if b.stones[p] == EmptyStone && !b.eye(p, next) && b.play(p, next) {
+				moved = true
+				break
+			}
+		}
+		if moved {
+			passes = 0
+		} else {
+			b.ko = -1
+			passes++
+		}
+		next = next.Opponent()
+	}
+	black, white := b.board().AreaScore()
+	if float64(black)-float64(white) > e.Komi {
+		return BlackStone
+	}
+	return WhiteStone
+}
+
+// playoutBoard is a board that is changed in place, which makes playouts
+// much faster than copying a Board for every move
+type playoutBoard struct {
+	width, height int
+	stones        []Stone
+	// the point that would take back a ko just taken, or -1
+	ko int
+	// marks the points visited by the current search
+	marks []int
+	mark  int
+	stack []int
+}
+
+func newPlayoutBoard(board Board) *playoutBoard {
+	b := &playoutBoard{
+		width:  board.Width(),
+		height: board.Height(),
+		ko:     -1,
+	}
+	b.stones = make([]Stone, b.width*b.height)
+	b.marks = make([]int, len(b.stones))
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			b.stones[y*b.width+x] = board.Get(x, y)
+		}
+	}
+	return b
+}
+
+// neighbors of a point on the board
+func (b *playoutBoard) neighbors(p int, found *[4]int) []int {
+	n := found[:0]
+	x, y := p%b.width, p/b.width
+	if x > 0 {
+		n = append(n, p-1)
+	}
+	if x < b.width-1 {
+		n = append(n, p+1)
+	}
+	if y > 0 {
+		n = append(n, p-b.width)
+	}
+	if y < b.height-1 {
+		n = append(n, p+b.width)
+	}
+	return n
+}
+
+// eye checks if all neighbors of a point are stones of one color
+func (b *playoutBoard) eye(p int, stone Stone) bool {
+	var found [4]int
+	for _, n := range b.neighbors(p, &found) {
+		if b.stones[n] != stone {
+			return false
+		}
+	}
+	return true
+}
+
+// free checks if the group of stones connected to a point has a liberty,
+// stopping as soon as it finds one
+func (b *playoutBoard) free(p int) bool {
+	var found [4]int
+	b.mark++
+	color := b.stones[p]
+	b.stack = append(b.stack[:0], p)
+	b.marks[p] = b.mark
+	for len(b.stack) > 0 {
+		q := b.stack[len(b.stack)-1]
+		b.stack = b.stack[:len(b.stack)-1]
+		for _, n := range b.neighbors(q, &found) {
+			if b.marks[n] == b.mark {
+				continue
+			}
+			switch b.stones[n] {
+			case EmptyStone:
+				return true
+			case color:
+				b.marks[n] = b.mark
+				b.stack = append(b.stack, n)
+			}
+		}
+	}
+	return false
+}
+
+// remove the group of stones connected to a point and return how many there
+// were
+func (b *playoutBoard) remove(p int) int {
+	var found [4]int
+	color := b.stones[p]
+	b.stones[p] = EmptyStone
+	b.stack = append(b.stack[:0], p)
+	removed := 1
+	for len(b.stack) > 0 {
+		q := b.stack[len(b.stack)-1]
+		b.stack = b.stack[:len(b.stack)-1]
+		for _, n := range b.neighbors(q, &found) {
+			if b.stones[n] == color {
+				b.stones[n] = EmptyStone
+				b.stack = append(b.stack, n)
+				removed++
+			}
+		}
+	}
+	return removed
+}
+
+// play a stone, capturing the groups it takes the last liberty of. Returns
+// false and leaves the board as it was if the move is suicide or retakes a
+// ko.
+func (b *playoutBoard) play(p int, stone Stone) bool {
+	if b.stones[p] != EmptyStone || p == b.ko {
+		return false
+	}
+	var found [4]int
+	b.stones[p] = stone
+	captured, last := 0, -1
+	alone := true
+	for _, n := range b.neighbors(p, &found) {
+		switch b.stones[n] {
+		case stone:
+			alone = false
+		case stone.Opponent():
+			if !b.free(n) {
+				captured += b.remove(n)
+				last = n
+			}
+		}
+	}
+	if captured == 0 && !b.free(p) {
+		b.stones[p] = EmptyStone
+		return false
+	}
+	b.ko = -1
+	if captured == 1 && alone && b.liberties(p) == 1 {
+		// taking back a single stone right away would repeat the board
+		b.ko = last
+	}
+	return true
+}
+
+// liberties of a single stone
+func (b *playoutBoard) liberties(p int) int {
+	var found [4]int
+	count := 0
+	for _, n := range b.neighbors(p, &found) {
+		if b.stones[n] == EmptyStone {
+			count++
+		}
+	}
+	return count
+}
+
+// board converts back to a Board
+func (b *playoutBoard) board() Board {
+	board := NewBoard(b.height)
+	for p, stone := range b.stones {
+		board[p/b.width][p%b.width] = stone
+	}
+	return board
+}
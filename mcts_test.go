@@ -0,0 +1,110 @@
+package gobot_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestMCTS(t *testing.T) {
+	newEngine := func(playouts int) *MCTS {
+		e := NewMCTS(NewRNG(1))
+		e.Playouts = playouts
+		e.Komi = 0
+		e.Clock = NewFakeClock(time.Now())
+		return e
+	}
+	cases := []struct {
+		name     string
+		playouts int
+		history  []string
+		next     Stone
+		move     Move
+	}{
+		{
+			name:     "the center of a small board",
+			playouts: 1000,
+			history:  []string{".../.../..."},
+			next:     BlackStone,
+			move:     Move{Coords: Coords{1, 1}},
+		}, {
+			// whoever plays first captures the other group
+			name:     "winning a capturing race",
+			playouts: 1000,
+			history:  []string{"WW.BW/WWWBW/BBBBW/WWWWW/....."},
+			next:     BlackStone,
+			move:     Move{Coords: Coords{2, 0}},
+		}, {
+			name:     "passing rather than filling an eye",
+			playouts: 100,
+			history:  []string{".B/BB"},
+			next:     BlackStone,
+			move:     Move{Pass: true},
+		}, {
+			// the only legal moves are inside white's two eyed group
+			name:     "passing rather than playing where white is alive",
+			playouts: 100,
+			history:  []string{".BW.W/BBWWW/.BW../BBWWW/.BW.W"},
+			next:     BlackStone,
+			move:     Move{Pass: true},
+		}, {
+			name:     "passing without playouts",
+			playouts: 0,
+			history:  []string{".../.../..."},
+			next:     BlackStone,
+			move:     Move{Pass: true},
+		},
+	}
+	for _, test := range cases {
+		history := History{}
+		for _, b := range test.history {
+			history = append(history, compactBoard(t, b))
+		}
+		m, err := newEngine(test.playouts).GenMove(history, test.next)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err.Error())
+		}
+		if *m != test.move {
+			t.Errorf("%s: expected %v but got %v", test.name, test.move, m)
+		}
+	}
+}
+
+func TestMCTSKo(t *testing.T) {
+	e := NewMCTS(NewRNG(1))
+	e.Playouts = 200
+	e.Clock = NewFakeClock(time.Now())
+	// black just took the ko at B3, and white may not take it back at B2
+	history := History{
+		compactBoard(t, ".BW./BW.W/.BW./...."),
+		compactBoard(t, ".BW./B.BW/.BW./...."),
+	}
+	for i := 0; i < 5; i++ {
+		m, err := e.GenMove(history, WhiteStone)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !m.Pass && m.Coords == (Coords{1, 1}) {
+			t.Fatalf("expected white not to retake the ko")
+		}
+	}
+}
+
+func TestMCTSLadder(t *testing.T) {
+	e := NewMCTS(NewRNG(1))
+	e.Playouts = 200
+	e.Clock = NewFakeClock(time.Now())
+	// black's stone at C3 is in atari and running would only make a ladder
+	history := History{compactBoard(t, "........./..W....../.WBW...../"+
+		".W......./........./........./........./........./.........")}
+	candidates, err := e.Analyze(history, BlackStone, 81)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for _, c := range candidates {
+		if c.Move.Coords == (Coords{2, 3}) && !c.Move.Pass {
+			t.Errorf("expected the engine not to run from the ladder")
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package gobot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps games in memory. It behaves like StateStore, so it can
+// stand in for a database in tests and local runs. If Path is set the games
+// are also written to a JSON file after every change and read back on Load.
+type MemoryStore struct {
+	// Path of the JSON snapshot file, or empty to keep games in memory only
+	Path   string
+	Clock  Clock
+	RNG    RNG
+	mu     sync.Mutex
+	games  map[int64]*memoryGame
+	events map[int64][]Event
+	last   int64
+}
+
+// memoryGame is a game as it was last saved
+type memoryGame struct {
+	ID      int64           `json:"id"`
+	Version int64           `json:"version"`
+	Blob    json.RawMessage `json:"blob"`
+	meta    Metadata
+}
+
+// memorySnapshot is the contents of the snapshot file
+type memorySnapshot struct {
+	Last   int64         `json:"last"`
+	Games  []*memoryGame `json:"games"`
+	Events []Event       `json:"events"`
+}
+
+// NewMemoryStore creates a store that keeps games in memory, snapshotting
+// them to a JSON file at path unless path is empty.
+func NewMemoryStore(path string) *MemoryStore {
+	return &MemoryStore{
+		Path:   path,
+		Clock:  SystemClock{},
+		games:  map[int64]*memoryGame{},
+		events: map[int64][]Event{},
+	}
+}
+
+// Use a clock for timestamps and vote timers, and a random source for
+// picking votes
+func (s *MemoryStore) Use(c Clock, r RNG) {
+	s.Clock = c
+	s.RNG = r
+}
+
+// Load reads the snapshot file if there is one
+func (s *MemoryStore) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Path == "" {
+		return nil
+	}
+	blob, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	snapshot := memorySnapshot{}
+	err = json.Unmarshal(blob, &snapshot)
+	if err != nil {
+		return fmt.Errorf("snapshot %s: %s", s.Path, err)
+	}
+	games := map[int64]*memoryGame{}
+	for _, g := range snapshot.Games {
+		game := &State{}
+		err = json.Unmarshal(g.Blob, game)
+		if err != nil {
+			return fmt.Errorf("snapshot %s: game %d: %s", s.Path, g.ID, err)
+		}
+		g.meta = game.Metadata()
+		games[g.ID] = g
+	}
+	events := map[int64][]Event{}
+	for _, e := range snapshot.Events {
+		events[e.Game] = append(events[e.Game], e)
+	}
+	s.games = games
+	s.events = events
+	s.last = snapshot.Last
+	return nil
+}
+
+// New creates a new Game and add it to the store
+func (s *MemoryStore) New(bp Blueprint) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	game := newState(bp, s.now())
+	blob, err := json.Marshal(game)
+	if err != nil {
+		return nil, err
+	}
+	s.last++
+	game.id = s.last
+	s.games[game.id] = &memoryGame{
+		ID: game.id, Blob: blob, meta: game.Metadata(),
+	}
+	s.events[game.id] = []Event{{
+		Game:      game.id,
+		Kind:      CreatedEvent,
+		Player:    bp.Creator,
+		At:        game.CreatedAt,
+		Blueprint: &bp,
+	}}
+	err = s.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	game.Use(s.Clock, s.RNG)
+	return NewSession(game, game, game, game), nil
+}
+
+// Get a game by id
+func (s *MemoryStore) Get(id int64) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok {
+		return nil, fmt.Errorf("game %d does not exist", id)
+	}
+	return s.session(g)
+}
+
+// Last returns the last state played
+func (s *MemoryStore) Last() (*Session, error) {
+	sessions, err := s.List(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, errors.New("no active games")
+	}
+	return sessions[0], nil
+}
+
+// LastFinished returns the finished game started last
+func (s *MemoryStore) LastFinished() (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var last *memoryGame
+	for _, g := range s.games {
+		if !g.meta.Finished || g.meta.Archived {
+			continue
+		}
+		if last == nil || g.meta.CreatedAt.After(last.meta.CreatedAt) ||
+			g.meta.CreatedAt.Equal(last.meta.CreatedAt) && g.ID > last.ID {
+			last = g
+		}
+	}
+	if last == nil {
+		return nil, errors.New("no finished games")
+	}
+	return s.session(last)
+}
+
+// Save a game, failing with a ConflictError if it was saved by someone else
+// since it was loaded
+func (s *MemoryStore) Save(storable Storable) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[storable.ID()]
+	if !ok || g.Version != storable.Version() {
+		return &ConflictError{ID: storable.ID()}
+	}
+	blob, err := storable.Save()
+	if err != nil {
+		return err
+	}
+	events := s.events[g.ID]
+	taken := storable.TakeEvents()
+	s.games[g.ID] = &memoryGame{
+		ID:      g.ID,
+		Version: g.Version + 1,
+		Blob:    blob,
+		meta:    storable.Metadata(),
+	}
+	s.events[g.ID] = append(events[:len(events):len(events)], taken...)
+	err = s.snapshot()
+	if err != nil {
+		// keep memory in step with the snapshot
+		s.games[g.ID] = g
+		s.events[g.ID] = events
+		putBack(storable, taken)
+		return err
+	}
+	storable.SetVersion(storable.Version() + 1)
+	return nil
+}
+
+// List gets a list of the games in the store that are not archived, most
+// recently updated first
+func (s *MemoryStore) List(all bool) ([]*Session, error) {
+	return s.list(func(meta Metadata) bool {
+		return !meta.Archived && (all || !meta.Finished)
+	})
+}
+
+// ListArchived gets a list of the archived games in the store, most recently
+// updated first
+func (s *MemoryStore) ListArchived() ([]*Session, error) {
+	return s.list(func(meta Metadata) bool {
+		return meta.Archived
+	})
+}
+
+// Search for the games matching a filter, most recently updated first, and
+// count how many match in all
+func (s *MemoryStore) Search(f Filter) ([]*Session, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	games := s.find(f.Match)
+	total := len(games)
+	if f.Offset > len(games) {
+		f.Offset = len(games)
+	}
+	games = games[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(games) {
+		games = games[:f.Limit]
+	}
+	sessions, err := s.sessions(games)
+	return sessions, total, err
+}
+
+// list the games matching a filter, most recently updated first
+func (s *MemoryStore) list(
+	match func(Metadata) bool,
+) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions(s.find(match))
+}
+
+// find the games matching a filter, most recently updated first. Must be
+// called with the store locked.
+func (s *MemoryStore) find(match func(Metadata) bool) []*memoryGame {
+	games := []*memoryGame{}
+	for _, g := range s.games {
+		if match(g.meta) {
+			games = append(games, g)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		a, b := games[i], games[j]
+		if a.meta.UpdatedAt.Equal(b.meta.UpdatedAt) {
+			return a.ID > b.ID
+		}
+		return a.meta.UpdatedAt.After(b.meta.UpdatedAt)
+	})
+	return games
+}
+
+// sessions loads games into sessions. Must be called with the store locked.
+func (s *MemoryStore) sessions(games []*memoryGame) ([]*Session, error) {
+	output := []*Session{}
+	for _, g := range games {
+		sess, err := s.session(g)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, sess)
+	}
+	return output, nil
+}
+
+// Archive a game so it is no longer listed
+func (s *MemoryStore) Archive(id int64) error {
+	return archive(s, id)
+}
+
+// Delete a game and its events
+func (s *MemoryStore) Delete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok {
+		return fmt.Errorf("game %d does not exist", id)
+	}
+	events := s.events[id]
+	delete(s.games, id)
+	delete(s.events, id)
+	err := s.snapshot()
+	if err != nil {
+		// keep memory in step with the snapshot
+		s.games[id] = g
+		s.events[id] = events
+		return err
+	}
+	return nil
+}
+
+// Restore an archived game, replacing any game with the same id
+func (s *MemoryStore) Restore(a ArchivedGame) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	game := &State{}
+	err := json.Unmarshal(a.Game, game)
+	if err != nil {
+		return err
+	}
+	blob, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	old, existed := s.games[a.ID]
+	oldEvents, last := s.events[a.ID], s.last
+	s.games[a.ID] = &memoryGame{
+		ID: a.ID, Version: a.Version, Blob: blob, meta: game.Metadata(),
+	}
+	s.events[a.ID] = restoredEvents(a)
+	if a.ID > s.last {
+		s.last = a.ID
+	}
+	err = s.snapshot()
+	if err != nil {
+		// keep memory in step with the snapshot
+		if existed {
+			s.games[a.ID] = old
+		} else {
+			delete(s.games, a.ID)
+		}
+		s.events[a.ID] = oldEvents
+		s.last = last
+		return err
+	}
+	return nil
+}
+
+// Events returns the last events of a game, oldest first, or all of them if
+// limit is not positive
+func (s *MemoryStore) Events(id int64, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.events[id]
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return append([]Event{}, events...), nil
+}
+
+// Close writes a final snapshot
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot()
+}
+
+// session loads a copy of a game so changes are only kept once saved
+func (s *MemoryStore) session(g *memoryGame) (*Session, error) {
+	game := &State{}
+	err := json.Unmarshal(g.Blob, game)
+	if err != nil {
+		return nil, err
+	}
+	game.id = g.ID
+	game.version = g.Version
+	game.Use(s.Clock, s.RNG)
+	return NewSession(game, game, game, game), nil
+}
+
+// snapshot writes every game to the snapshot file. The file is replaced in
+// one step so a crash never leaves half a snapshot behind. Must be called
+// with the store locked.
+func (s *MemoryStore) snapshot() error {
+	if s.Path == "" {
+		return nil
+	}
+	snapshot := memorySnapshot{
+		Last:   s.last,
+		Games:  []*memoryGame{},
+		Events: []Event{},
+	}
+	for _, g := range s.games {
+		snapshot.Games = append(snapshot.Games, g)
+	}
+	sort.Slice(snapshot.Games, func(i, j int) bool {
+		return snapshot.Games[i].ID < snapshot.Games[j].ID
+	})
+	for _, g := range snapshot.Games {
+		snapshot.Events = append(snapshot.Events, s.events[g.ID]...)
+	}
+	blob, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(s.Path), ".gobot")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(blob)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), s.Path)
+}
+
+func (s *MemoryStore) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
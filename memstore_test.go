@@ -0,0 +1,170 @@
+package gobot_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore("")
+	testStore(t, "memory", store)
+	_, err := store.Get(100)
+	if err == nil {
+		t.Errorf("expected an error getting a missing game")
+	}
+}
+
+func TestMemoryStoreOrder(t *testing.T) {
+	clock := NewFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemoryStore("")
+	store.Use(clock, NewRNG(1))
+	ids := []int64{}
+	for i := 0; i < 3; i++ {
+		sess, err := store.New(Blueprint{})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		ids = append(ids, sess.Storable.ID())
+		clock.Advance(time.Minute)
+	}
+	if ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Errorf("expected ids 1, 2, 3 but got %v", ids)
+	}
+	// finish the first game and touch the second
+	first, _ := store.Get(1)
+	first.Game.Move(&Move{Pass: true})
+	first.Game.Move(&Move{Pass: true})
+	store.Save(first.Storable)
+	clock.Advance(time.Minute)
+	second, _ := store.Get(2)
+	store.Save(second.Storable)
+	cases := []struct {
+		all bool
+		ids []int64
+	}{
+		{all: false, ids: []int64{2, 3}},
+		{all: true, ids: []int64{2, 1, 3}},
+	}
+	for _, test := range cases {
+		list, err := store.List(test.all)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		got := []int64{}
+		for _, sess := range list {
+			got = append(got, sess.Storable.ID())
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.ids) {
+			t.Errorf("list %t: expected %v but got %v", test.all, test.ids, got)
+		}
+	}
+	last, err := store.Last()
+	if err != nil || last.Storable.ID() != 2 {
+		t.Errorf("expected last game 2 but got %v", err)
+	}
+	finished, err := store.LastFinished()
+	if err != nil || finished.Storable.ID() != 1 {
+		t.Errorf("expected last finished game 1 but got %v", err)
+	}
+	// the finished game started last wins, whichever was updated last
+	third, _ := store.Get(3)
+	third.Game.Move(&Move{Pass: true})
+	third.Game.Move(&Move{Pass: true})
+	store.Save(third.Storable)
+	clock.Advance(time.Minute)
+	first, _ = store.Get(1)
+	store.Save(first.Storable)
+	finished, err = store.LastFinished()
+	if err != nil || finished.Storable.ID() != 3 {
+		t.Errorf("expected last finished game 3 but got %v", err)
+	}
+}
+
+func TestMemoryStoreSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobot")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "games.json")
+
+	store := NewMemoryStore(path)
+	err = store.Load()
+	if err != nil {
+		t.Fatalf("loading without a snapshot: %s", err.Error())
+	}
+	sess, err := store.New(Blueprint{Channel: "C1"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	sess.Game.Move(&Move{Coords: Coords{3, 3}})
+	err = store.Save(sess.Storable)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	reopened := NewMemoryStore(path)
+	err = reopened.Load()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	got, err := reopened.Get(sess.Storable.ID())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got.Storable.Version() != 1 || got.Playable.Channel() != "C1" {
+		t.Errorf(
+			"got version %d in %s",
+			got.Storable.Version(), got.Playable.Channel(),
+		)
+	}
+	if got.Game.Board()[3][3] != BlackStone {
+		t.Errorf("expected the move to be restored")
+	}
+	// ids keep counting from the snapshot
+	next, err := reopened.New(Blueprint{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if next.Storable.ID() != 2 {
+		t.Errorf("expected game 2 but got %d", next.Storable.ID())
+	}
+}
+
+func TestMemoryStoreConcurrent(t *testing.T) {
+	store := NewMemoryStore("")
+	ids := make(chan int64, 20)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sess, err := store.New(Blueprint{})
+			if err != nil {
+				t.Errorf(err.Error())
+				return
+			}
+			ids <- sess.Storable.ID()
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	seen := map[int64]bool{}
+	for id := range ids {
+		if seen[id] {
+			t.Errorf("game %d was created twice", id)
+		}
+		seen[id] = true
+	}
+	list, _ := store.List(false)
+	if len(list) != 20 {
+		t.Errorf("expected 20 games but got %d", len(list))
+	}
+}
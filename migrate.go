@@ -0,0 +1,438 @@
+package gobot
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Migration upgrades the database schema by one version
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(*sql.Tx) error
+}
+
+// Migrations upgrade the games database in SQLite in order. Append new
+// migrations to the end of the list and never change ones that have been
+// released.
+var Migrations = []Migration{
+	{1, "create games table", execMigration(`
+		CREATE TABLE IF NOT EXISTS
+		games
+		(
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			blob BLOB NOT NULL
+		)
+	`)},
+	{2, "add version column to games", addVersionColumn},
+	{3, "store boards in the compact format", compactBoards},
+	{4, "add queryable metadata columns to games", addMetadataColumns},
+	{5, "create events table", execMigration(`
+		CREATE TABLE IF NOT EXISTS
+		events
+		(
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			game_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			player TEXT NOT NULL DEFAULT '',
+			blob BLOB NOT NULL,
+			created_at INTEGER NOT NULL DEFAULT 0
+		)
+	`,
+		`CREATE INDEX events_game_id ON events (game_id, id)`,
+	)},
+	{6, "add archived column to games", execMigration(
+		`ALTER TABLE games ADD COLUMN archived INTEGER NOT NULL DEFAULT 0`,
+		`CREATE INDEX games_archived_updated_at ON games (archived, updated_at)`,
+	)},
+	{7, "add waiting column to games", addWaitingColumn(sqliteDialect{})},
+}
+
+// PostgresMigrations upgrade the games database in PostgreSQL in order.
+// Support for PostgreSQL was added after the fourth SQLite migration, so the
+// first one creates the same schema at once. New migrations are appended to
+// both lists with the same version.
+var PostgresMigrations = []Migration{
+	{4, "create games table", execMigration(`
+		CREATE TABLE IF NOT EXISTS
+		games
+		(
+			id BIGSERIAL PRIMARY KEY,
+			blob BYTEA NOT NULL,
+			version BIGINT NOT NULL DEFAULT 0,
+			finished BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at BIGINT NOT NULL DEFAULT 0,
+			updated_at BIGINT NOT NULL DEFAULT 0,
+			channel TEXT NOT NULL DEFAULT '',
+			players TEXT NOT NULL DEFAULT ''
+		)
+	`,
+		`CREATE INDEX games_finished_updated_at ON games (finished, updated_at)`,
+		`CREATE INDEX games_updated_at ON games (updated_at)`,
+		`CREATE INDEX games_channel ON games (channel)`,
+	)},
+	{5, "create events table", execMigration(`
+		CREATE TABLE IF NOT EXISTS
+		events
+		(
+			id BIGSERIAL PRIMARY KEY,
+			game_id BIGINT NOT NULL,
+			kind TEXT NOT NULL,
+			player TEXT NOT NULL DEFAULT '',
+			blob BYTEA NOT NULL,
+			created_at BIGINT NOT NULL DEFAULT 0
+		)
+	`,
+		`CREATE INDEX events_game_id ON events (game_id, id)`,
+	)},
+	{6, "add archived column to games", execMigration(
+		`ALTER TABLE games ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE`,
+		`CREATE INDEX games_archived_updated_at ON games (archived, updated_at)`,
+	)},
+	{7, "add waiting column to games", addWaitingColumn(postgresDialect{})},
+}
+
+// Migrate applies every migration newer than the current schema version.
+// Each migration runs in its own transaction along with the bump of the
+// schema version, so a failed migration can simply be retried.
+func Migrate(db *sql.DB, d Dialect, migrations []Migration) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS
+		schema_version
+		(
+			version INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		err = migrate(db, d, m)
+		if err != nil {
+			return fmt.Errorf(
+				"migration %d (%s) failed: %s", m.Version, m.Description, err,
+			)
+		}
+		current = m.Version
+	}
+	return nil
+}
+
+// SchemaVersion returns the version of the last migration applied
+func SchemaVersion(db *sql.DB) (int, error) {
+	row := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`)
+	var version int
+	err := row.Scan(&version)
+	return version, err
+}
+
+func migrate(db *sql.DB, d Dialect, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	err = m.Up(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(
+		d.Rebind(`INSERT INTO schema_version (version) VALUES(?)`), m.Version,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func execMigration(stmts ...string) func(*sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, stmt := range stmts {
+			_, err := tx.Exec(stmt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func addVersionColumn(tx *sql.Tx) error {
+	// databases created before migrations existed may already have it
+	row := tx.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('games')
+		WHERE name = 'version'
+	`)
+	var count int
+	err := row.Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = tx.Exec(
+		`ALTER TABLE games ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+	)
+	return err
+}
+
+// compactBoards rewrites every game so its boards are stored as strings
+// instead of nested arrays of stones. Blobs are read as raw JSON so that the
+// migration does not change with the State type.
+func compactBoards(tx *sql.Tx) error {
+	blobs, err := readBlobs(tx)
+	if err != nil {
+		return err
+	}
+	for id, blob := range blobs {
+		blob, err = compactBlob(blob)
+		if err != nil {
+			return fmt.Errorf("game %d: %s", id, err)
+		}
+		_, err = tx.Exec(`UPDATE games SET blob = ? WHERE id = ?`, blob, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactBlob rewrites the boards in the history of a game blob as strings,
+// leaving every other field as it was
+func compactBlob(blob []byte) ([]byte, error) {
+	game := map[string]json.RawMessage{}
+	err := json.Unmarshal(blob, &game)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := game["history"]
+	if !ok {
+		return blob, nil
+	}
+	history := []json.RawMessage{}
+	err = json.Unmarshal(raw, &history)
+	if err != nil {
+		return nil, err
+	}
+	for i, board := range history {
+		history[i], err = compactBoard(board)
+		if err != nil {
+			return nil, err
+		}
+	}
+	game["history"], err = json.Marshal(history)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(game)
+}
+
+// compactBoard encodes a board of nested arrays of stones as its rows of
+// letters separated by slashes. Boards already in that format are kept.
+func compactBoard(board json.RawMessage) (json.RawMessage, error) {
+	var legacy [][]int
+	if err := json.Unmarshal(board, &legacy); err != nil {
+		var compact string
+		if json.Unmarshal(board, &compact) == nil {
+			return board, nil
+		}
+		return nil, err
+	}
+	letters := map[int]byte{0: '.', 1: 'B', 2: 'W'}
+	rows := make([]string, len(legacy))
+	for i, row := range legacy {
+		encoded := make([]byte, len(row))
+		for j, stone := range row {
+			letter, ok := letters[stone]
+			if !ok {
+				return nil, fmt.Errorf("cannot encode stone %d", stone)
+			}
+			encoded[j] = letter
+		}
+		rows[i] = string(encoded)
+	}
+	return json.Marshal(strings.Join(rows, "/"))
+}
+
+// migration4Game is the part of a game blob that migration 4 reads, frozen
+// as it was when the migration was released
+type migration4Game struct {
+	Players struct {
+		Black []string `json:"black"`
+		White []string `json:"white"`
+	} `json:"players"`
+	Passes struct {
+		Black bool `json:"black"`
+		White bool `json:"white"`
+	} `json:"passes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ChannelID string    `json:"channel"`
+}
+
+// unixNano is a time in nanoseconds, or 0 for the zero time
+func (g *migration4Game) unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// playerList joins the unique players of migration 4
+func (g *migration4Game) playerList() string {
+	return migrationPlayerList(append(g.Players.Black, g.Players.White...))
+}
+
+// addMetadataColumns adds indexed columns describing each game, filled in
+// from the existing blobs, so games can be listed without loading them all.
+func addMetadataColumns(tx *sql.Tx) error {
+	err := execMigration(
+		`ALTER TABLE games ADD COLUMN finished INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE games ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE games ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE games ADD COLUMN channel TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE games ADD COLUMN players TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX games_finished_updated_at ON games (finished, updated_at)`,
+		`CREATE INDEX games_updated_at ON games (updated_at)`,
+		`CREATE INDEX games_channel ON games (channel)`,
+	)(tx)
+	if err != nil {
+		return err
+	}
+	blobs, err := readBlobs(tx)
+	if err != nil {
+		return err
+	}
+	for id, blob := range blobs {
+		game := &migration4Game{}
+		err = json.Unmarshal(blob, game)
+		if err != nil {
+			return fmt.Errorf("game %d: %s", id, err)
+		}
+		_, err = tx.Exec(`
+			UPDATE games SET
+				finished = ?, created_at = ?, updated_at = ?,
+				channel = ?, players = ?
+			WHERE id = ?
+		`,
+			game.Passes.Black && game.Passes.White,
+			game.unixNano(game.CreatedAt), game.unixNano(game.UpdatedAt),
+			game.ChannelID, game.playerList(), id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addWaitingColumn adds a column naming the players who can make the next
+// move in each game, filled in from the existing blobs, and an index to find
+// the newest games. Players are matched with LIKE '% player %', which no
+// index can help with, so queries on them are narrowed by the other indexes.
+func addWaitingColumn(d Dialect) func(*sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		err := execMigration(
+			`ALTER TABLE games ADD COLUMN waiting TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX games_finished_created_at ON games (finished, created_at)`,
+		)(tx)
+		if err != nil {
+			return err
+		}
+		blobs, err := readBlobs(tx)
+		if err != nil {
+			return err
+		}
+		for id, blob := range blobs {
+			game := &migration7Game{}
+			err = json.Unmarshal(blob, game)
+			if err != nil {
+				return fmt.Errorf("game %d: %s", id, err)
+			}
+			_, err = tx.Exec(
+				d.Rebind(`UPDATE games SET waiting = ? WHERE id = ?`),
+				game.waitingList(), id,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// migration7Game is the part of a game blob that migration 7 reads, frozen
+// as it was when the migration was released
+type migration7Game struct {
+	Players struct {
+		Black  []string `json:"black"`
+		White  []string `json:"white"`
+		Anyone bool     `json:"anyone"`
+	} `json:"players"`
+	Next   int `json:"next"`
+	Passes struct {
+		Black bool `json:"black"`
+		White bool `json:"white"`
+	} `json:"passes"`
+}
+
+// waitingList joins the players who can make the next move in a two player
+// game that is not finished
+func (g *migration7Game) waitingList() string {
+	if g.Players.Anyone || (g.Passes.Black && g.Passes.White) {
+		return ""
+	}
+	// 2 was white in the stones of the game blobs
+	if g.Next == 2 {
+		return migrationPlayerList(g.Players.White)
+	}
+	return migrationPlayerList(g.Players.Black)
+}
+
+// migrationPlayerList joins the unique players, sorted, with spaces and pads
+// them with a space on both ends
+func migrationPlayerList(list []string) string {
+	seen := map[string]bool{}
+	players := []string{}
+	for _, p := range list {
+		if !seen[p] {
+			seen[p] = true
+			players = append(players, p)
+		}
+	}
+	if len(players) == 0 {
+		return ""
+	}
+	sort.Strings(players)
+	return " " + strings.Join(players, " ") + " "
+}
+
+// readBlobs reads every game blob by id
+func readBlobs(tx *sql.Tx) (map[int64][]byte, error) {
+	rows, err := tx.Query(`SELECT id, blob FROM games`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	blobs := map[int64][]byte{}
+	for rows.Next() {
+		var id int64
+		var blob []byte
+		err = rows.Scan(&id, &blob)
+		if err != nil {
+			return nil, err
+		}
+		blobs[id] = blob
+	}
+	return blobs, rows.Err()
+}
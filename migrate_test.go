@@ -0,0 +1,105 @@
+package gobot_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	. "github.com/crestonbunch/gobot"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestMigrate(t *testing.T) {
+	migrations := []Migration{
+		{1, "first", func(tx *sql.Tx) error {
+			_, err := tx.Exec("CREATE TABLE first (id INTEGER)")
+			return err
+		}},
+		{2, "second", func(tx *sql.Tx) error {
+			_, err := tx.Exec("CREATE TABLE second (id INTEGER)")
+			return err
+		}},
+		{3, "broken", func(tx *sql.Tx) error {
+			return errors.New("broken")
+		}},
+	}
+	cases := []struct {
+		desc    string
+		current int
+		list    []Migration
+		setup   func(sqlmock.Sqlmock)
+		err     bool
+	}{
+		{
+			desc:    "up to date",
+			current: 2,
+			list:    migrations[:2],
+			setup:   func(mock sqlmock.Sqlmock) {},
+		}, {
+			desc:    "applies newer migrations in order",
+			current: 0,
+			list:    migrations[:2],
+			setup: func(mock sqlmock.Sqlmock) {
+				for i, table := range []string{"first", "second"} {
+					mock.ExpectBegin()
+					mock.ExpectExec("CREATE TABLE " + table + ".+").
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("INSERT INTO schema_version.+").
+						WithArgs(i + 1).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				}
+			},
+		}, {
+			desc:    "rolls back a failed migration",
+			current: 2,
+			list:    migrations,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			err: true,
+		},
+	}
+	for _, test := range cases {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		defer db.Close()
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS.+schema_version.+").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT COALESCE.+").
+			WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow(test.current))
+		test.setup(mock)
+		err = Migrate(db, SQLite, test.list)
+		if err != nil && !test.err {
+			t.Errorf("%s: unexpected error %s", test.desc, err.Error())
+		}
+		if err == nil && test.err {
+			t.Errorf("%s: expected error", test.desc)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %s", test.desc, err.Error())
+		}
+	}
+}
+
+func TestMigrationVersions(t *testing.T) {
+	for i, m := range Migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %q has version %d", m.Description, m.Version)
+		}
+	}
+	// postgres starts at the sqlite version it was added in and keeps pace
+	first := PostgresMigrations[0].Version
+	for i, m := range PostgresMigrations {
+		if m.Version != first+i {
+			t.Errorf("migration %q has version %d", m.Description, m.Version)
+		}
+	}
+	last := PostgresMigrations[len(PostgresMigrations)-1]
+	if last.Version != len(Migrations) {
+		t.Errorf("postgres migrations stop at version %d", last.Version)
+	}
+}
@@ -6,7 +6,6 @@ package mocks
 
 import (
 	reflect "reflect"
-	time "time"
 
 	gobot "github.com/crestonbunch/gobot"
 	gomock "github.com/golang/mock/gomock"
@@ -48,9 +47,9 @@ func (mr *MockVotableMockRecorder) Vote(arg0 interface{}) *gomock.Call {
 }
 
 // Schedule mocks base method
-func (m *MockVotable) Schedule() *time.Timer {
+func (m *MockVotable) Schedule() gobot.Timer {
 	ret := m.ctrl.Call(m, "Schedule")
-	ret0, _ := ret[0].(*time.Timer)
+	ret0, _ := ret[0].(gobot.Timer)
 	return ret0
 }
 
@@ -96,6 +95,52 @@ func (mr *MockVotableMockRecorder) Reset() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockVotable)(nil).Reset))
 }
 
+// Required mocks base method
+func (m *MockVotable) Required() bool {
+	ret := m.ctrl.Call(m, "Required")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Required indicates an expected call of Required
+func (mr *MockVotableMockRecorder) Required() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Required", reflect.TypeOf((*MockVotable)(nil).Required))
+}
+
+// Rules mocks base method
+func (m *MockVotable) Rules() gobot.Voting {
+	ret := m.ctrl.Call(m, "Rules")
+	ret0, _ := ret[0].(gobot.Voting)
+	return ret0
+}
+
+// Rules indicates an expected call of Rules
+func (mr *MockVotableMockRecorder) Rules() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rules", reflect.TypeOf((*MockVotable)(nil).Rules))
+}
+
+// SetRules mocks base method
+func (m *MockVotable) SetRules(arg0 gobot.Voting) error {
+	ret := m.ctrl.Call(m, "SetRules", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRules indicates an expected call of SetRules
+func (mr *MockVotableMockRecorder) SetRules(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRules", reflect.TypeOf((*MockVotable)(nil).SetRules), arg0)
+}
+
+// Use mocks base method
+func (m *MockVotable) Use(arg0 gobot.Clock, arg1 gobot.RNG) {
+	m.ctrl.Call(m, "Use", arg0, arg1)
+}
+
+// Use indicates an expected call of Use
+func (mr *MockVotableMockRecorder) Use(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockVotable)(nil).Use), arg0, arg1)
+}
+
 // MockStorable is a mock of Storable interface
 type MockStorable struct {
 	ctrl     *gomock.Controller
@@ -156,6 +201,74 @@ func (mr *MockStorableMockRecorder) Save() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockStorable)(nil).Save))
 }
 
+// Version mocks base method
+func (m *MockStorable) Version() int64 {
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// Version indicates an expected call of Version
+func (mr *MockStorableMockRecorder) Version() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockStorable)(nil).Version))
+}
+
+// SetVersion mocks base method
+func (m *MockStorable) SetVersion(arg0 int64) {
+	m.ctrl.Call(m, "SetVersion", arg0)
+}
+
+// SetVersion indicates an expected call of SetVersion
+func (mr *MockStorableMockRecorder) SetVersion(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVersion", reflect.TypeOf((*MockStorable)(nil).SetVersion), arg0)
+}
+
+// Metadata mocks base method
+func (m *MockStorable) Metadata() gobot.Metadata {
+	ret := m.ctrl.Call(m, "Metadata")
+	ret0, _ := ret[0].(gobot.Metadata)
+	return ret0
+}
+
+// Metadata indicates an expected call of Metadata
+func (mr *MockStorableMockRecorder) Metadata() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Metadata", reflect.TypeOf((*MockStorable)(nil).Metadata))
+}
+
+// Record mocks base method
+func (m *MockStorable) Record(arg0 gobot.Event) {
+	m.ctrl.Call(m, "Record", arg0)
+}
+
+// Record indicates an expected call of Record
+func (mr *MockStorableMockRecorder) Record(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockStorable)(nil).Record), arg0)
+}
+
+// TakeEvents mocks base method
+func (m *MockStorable) TakeEvents() []gobot.Event {
+	ret := m.ctrl.Call(m, "TakeEvents")
+	ret0, _ := ret[0].([]gobot.Event)
+	return ret0
+}
+
+// TakeEvents indicates an expected call of TakeEvents
+func (mr *MockStorableMockRecorder) TakeEvents() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeEvents", reflect.TypeOf((*MockStorable)(nil).TakeEvents))
+}
+
+// Unsaved mocks base method
+func (m *MockStorable) Unsaved() bool {
+	ret := m.ctrl.Call(m, "Unsaved")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Unsaved indicates an expected call of Unsaved
+func (mr *MockStorableMockRecorder) Unsaved() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsaved", reflect.TypeOf((*MockStorable)(nil).Unsaved))
+}
+
 // MockPlayable is a mock of Playable interface
 type MockPlayable struct {
 	ctrl     *gomock.Controller
@@ -203,6 +316,18 @@ func (mr *MockPlayableMockRecorder) CanMove(playerID interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CanMove", reflect.TypeOf((*MockPlayable)(nil).CanMove), playerID)
 }
 
+// Channel mocks base method
+func (m *MockPlayable) Channel() string {
+	ret := m.ctrl.Call(m, "Channel")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Channel indicates an expected call of Channel
+func (mr *MockPlayableMockRecorder) Channel() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Channel", reflect.TypeOf((*MockPlayable)(nil).Channel))
+}
+
 // MockGame is a mock of Game interface
 type MockGame struct {
 	ctrl     *gomock.Controller
@@ -250,6 +375,53 @@ func (mr *MockGameMockRecorder) Finished() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finished", reflect.TypeOf((*MockGame)(nil).Finished))
 }
 
+// Archived mocks base method
+func (m *MockGame) Archived() bool {
+	ret := m.ctrl.Call(m, "Archived")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Archived indicates an expected call of Archived
+func (mr *MockGameMockRecorder) Archived() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Archived", reflect.TypeOf((*MockGame)(nil).Archived))
+}
+
+// Archive mocks base method
+func (m *MockGame) Archive() {
+	m.ctrl.Call(m, "Archive")
+}
+
+// Archive indicates an expected call of Archive
+func (mr *MockGameMockRecorder) Archive() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Archive", reflect.TypeOf((*MockGame)(nil).Archive))
+}
+
+// Summary mocks base method
+func (m *MockGame) Summary() gobot.Summary {
+	ret := m.ctrl.Call(m, "Summary")
+	ret0, _ := ret[0].(gobot.Summary)
+	return ret0
+}
+
+// Summary indicates an expected call of Summary
+func (mr *MockGameMockRecorder) Summary() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Summary", reflect.TypeOf((*MockGame)(nil).Summary))
+}
+
+// Position mocks base method
+func (m *MockGame) Position() (gobot.History, gobot.Stone) {
+	ret := m.ctrl.Call(m, "Position")
+	ret0, _ := ret[0].(gobot.History)
+	ret1, _ := ret[1].(gobot.Stone)
+	return ret0, ret1
+}
+
+// Position indicates an expected call of Position
+func (mr *MockGameMockRecorder) Position() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Position", reflect.TypeOf((*MockGame)(nil).Position))
+}
+
 // Move mocks base method
 func (m *MockGame) Move(arg0 *gobot.Move) error {
 	ret := m.ctrl.Call(m, "Move", arg0)
@@ -262,6 +434,18 @@ func (mr *MockGameMockRecorder) Move(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockGame)(nil).Move), arg0)
 }
 
+// Resign mocks base method
+func (m *MockGame) Resign() error {
+	ret := m.ctrl.Call(m, "Resign")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Resign indicates an expected call of Resign
+func (mr *MockGameMockRecorder) Resign() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resign", reflect.TypeOf((*MockGame)(nil).Resign))
+}
+
 // Validate mocks base method
 func (m *MockGame) Validate(arg0 *gobot.Move) bool {
 	ret := m.ctrl.Call(m, "Validate", arg0)
@@ -360,6 +544,19 @@ func (mr *MockStoreMockRecorder) Last() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Last", reflect.TypeOf((*MockStore)(nil).Last))
 }
 
+// LastFinished mocks base method
+func (m *MockStore) LastFinished() (*gobot.Session, error) {
+	ret := m.ctrl.Call(m, "LastFinished")
+	ret0, _ := ret[0].(*gobot.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastFinished indicates an expected call of LastFinished
+func (mr *MockStoreMockRecorder) LastFinished() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastFinished", reflect.TypeOf((*MockStore)(nil).LastFinished))
+}
+
 // Save mocks base method
 func (m *MockStore) Save(arg0 gobot.Storable) error {
 	ret := m.ctrl.Call(m, "Save", arg0)
@@ -384,3 +581,89 @@ func (m *MockStore) List(all bool) ([]*gobot.Session, error) {
 func (mr *MockStoreMockRecorder) List(all interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStore)(nil).List), all)
 }
+
+// ListArchived mocks base method
+func (m *MockStore) ListArchived() ([]*gobot.Session, error) {
+	ret := m.ctrl.Call(m, "ListArchived")
+	ret0, _ := ret[0].([]*gobot.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchived indicates an expected call of ListArchived
+func (mr *MockStoreMockRecorder) ListArchived() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchived", reflect.TypeOf((*MockStore)(nil).ListArchived))
+}
+
+// Search mocks base method
+func (m *MockStore) Search(arg0 gobot.Filter) ([]*gobot.Session, int, error) {
+	ret := m.ctrl.Call(m, "Search", arg0)
+	ret0, _ := ret[0].([]*gobot.Session)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Search indicates an expected call of Search
+func (mr *MockStoreMockRecorder) Search(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockStore)(nil).Search), arg0)
+}
+
+// Archive mocks base method
+func (m *MockStore) Archive(id int64) error {
+	ret := m.ctrl.Call(m, "Archive", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Archive indicates an expected call of Archive
+func (mr *MockStoreMockRecorder) Archive(id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Archive", reflect.TypeOf((*MockStore)(nil).Archive), id)
+}
+
+// Delete mocks base method
+func (m *MockStore) Delete(id int64) error {
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockStoreMockRecorder) Delete(id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStore)(nil).Delete), id)
+}
+
+// Events mocks base method
+func (m *MockStore) Events(id int64, limit int) ([]gobot.Event, error) {
+	ret := m.ctrl.Call(m, "Events", id, limit)
+	ret0, _ := ret[0].([]gobot.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Events indicates an expected call of Events
+func (mr *MockStoreMockRecorder) Events(id, limit interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockStore)(nil).Events), id, limit)
+}
+
+// Restore mocks base method
+func (m *MockStore) Restore(arg0 gobot.ArchivedGame) error {
+	ret := m.ctrl.Call(m, "Restore", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore
+func (mr *MockStoreMockRecorder) Restore(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockStore)(nil).Restore), arg0)
+}
+
+// Use mocks base method
+func (m *MockStore) Use(arg0 gobot.Clock, arg1 gobot.RNG) {
+	m.ctrl.Call(m, "Use", arg0, arg1)
+}
+
+// Use indicates an expected call of Use
+func (mr *MockStoreMockRecorder) Use(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockStore)(nil).Use), arg0, arg1)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // StartRegex matches a start command
@@ -12,12 +14,21 @@ var StartRegex = regexp.MustCompile("^start$")
 // TwoPlayerStartRegex matches a start command with two players
 var TwoPlayerStartRegex = regexp.MustCompile("^start ([^ ]+) ([^ ]+)$")
 
+// TimedStartRegex matches a start command with a vote duration
+var TimedStartRegex = regexp.MustCompile("^start every ([^ ]+)$")
+
 // MoveRegex matches a move command
 var MoveRegex = regexp.MustCompile("^move (pass|[A-Z][0-9]+)$")
 
 // GameMoveRegex matches a move command for a specific game
 var GameMoveRegex = regexp.MustCompile("^move ([0-9]+) (pass|[A-Z][0-9]+)$")
 
+// ResignRegex matches a resign command
+var ResignRegex = regexp.MustCompile("^resign$")
+
+// GameResignRegex matches a resign command for a specific game
+var GameResignRegex = regexp.MustCompile("^resign ([0-9]+)$")
+
 // VoteRegex matches a vote command
 var VoteRegex = regexp.MustCompile("^vote (pass|[A-Z][0-9]+)$")
 
@@ -36,11 +47,68 @@ var ShowRegex = regexp.MustCompile("^show$")
 // GameShowRegex matches a show command for a specific game
 var GameShowRegex = regexp.MustCompile("^show ([0-9]+)$")
 
-// ListRegex matches a list command
-var ListRegex = regexp.MustCompile("^list$")
+// ListRegex matches a list command. Every list command can end with the
+// page of games to show.
+var ListRegex = regexp.MustCompile("^list(?: ([0-9]+))?$")
 
 // ListAllRegex matches a list all command
-var ListAllRegex = regexp.MustCompile("^list all$")
+var ListAllRegex = regexp.MustCompile("^list all(?: ([0-9]+))?$")
+
+// ListArchivedRegex matches a list archived command
+var ListArchivedRegex = regexp.MustCompile("^list archived(?: ([0-9]+))?$")
+
+// ListMineRegex matches a list command for the games of the player asking
+var ListMineRegex = regexp.MustCompile("^list mine(?: ([0-9]+))?$")
+
+// ListWaitingRegex matches a list command for the games waiting on the
+// player asking
+var ListWaitingRegex = regexp.MustCompile("^list waiting(?: ([0-9]+))?$")
+
+// ListPlayerRegex matches a list command for the games of a player
+var ListPlayerRegex = regexp.MustCompile(
+	"^list @?([A-Za-z][^ ]*)(?: ([0-9]+))?$",
+)
+
+// SetRegex matches a set command
+var SetRegex = regexp.MustCompile("^set (vote-duration|vote-window) (.+)$")
+
+// GameSetRegex matches a set command for a specific game
+var GameSetRegex = regexp.MustCompile(
+	"^set ([0-9]+) (vote-duration|vote-window) (.+)$",
+)
+
+// LogRegex matches a log command
+var LogRegex = regexp.MustCompile("^log$")
+
+// GameLogRegex matches a log command for a specific game
+var GameLogRegex = regexp.MustCompile("^log ([0-9]+)$")
+
+// ArchiveRegex matches an archive command
+var ArchiveRegex = regexp.MustCompile("^archive$")
+
+// GameArchiveRegex matches an archive command for a specific game
+var GameArchiveRegex = regexp.MustCompile("^archive ([0-9]+)$")
+
+// HintRegex matches a hint command, which is sent privately if asked
+var HintRegex = regexp.MustCompile("^hint( private)?$")
+
+// GameHintRegex matches a hint command for a specific game
+var GameHintRegex = regexp.MustCompile("^hint ([0-9]+)( private)?$")
+
+// EstimateRegex matches an estimate command, or score for short
+var EstimateRegex = regexp.MustCompile("^(?:estimate|score)$")
+
+// GameEstimateRegex matches an estimate command for a specific game
+var GameEstimateRegex = regexp.MustCompile("^(?:estimate|score) ([0-9]+)$")
+
+// ReviewRegex matches a review command
+var ReviewRegex = regexp.MustCompile("^review$")
+
+// GameReviewRegex matches a review command for a specific game
+var GameReviewRegex = regexp.MustCompile("^review ([0-9]+)$")
+
+// DeleteRegex matches a delete command, which always names the game
+var DeleteRegex = regexp.MustCompile("^delete ([0-9]+)$")
 
 // Locator describes rules for picking which session a command should
 // be sent to. Either pick a specific session, or pick the session
@@ -62,6 +130,8 @@ func (loc Locator) Find(str Store) (*Session, error) {
 type Blueprint struct {
 	Players Players
 	Voting  Voting
+	Channel string
+	Creator string
 }
 
 // ParseCommand parses a command from an input string
@@ -70,6 +140,10 @@ func ParseCommand(input string) (Command, error) {
 		matches := StartRegex.FindStringSubmatch(input)
 		return parseStartCommand(matches[1:])
 	}
+	if TimedStartRegex.MatchString(input) {
+		matches := TimedStartRegex.FindStringSubmatch(input)
+		return parseTimedStartCommand(matches[1:])
+	}
 	if TwoPlayerStartRegex.MatchString(input) {
 		matches := TwoPlayerStartRegex.FindStringSubmatch(input)
 		return parseStartCommand(matches[1:])
@@ -107,10 +181,83 @@ func ParseCommand(input string) (Command, error) {
 		return parseGameShowCommand(matches[1:])
 	}
 	if ListRegex.MatchString(input) {
-		return parseListRegex()
+		matches := ListRegex.FindStringSubmatch(input)
+		return parseListCommand(&ListCommand{}, matches[1])
 	}
 	if ListAllRegex.MatchString(input) {
-		return parseListAllRegex()
+		matches := ListAllRegex.FindStringSubmatch(input)
+		return parseListCommand(&ListCommand{All: true}, matches[1])
+	}
+	if ListArchivedRegex.MatchString(input) {
+		matches := ListArchivedRegex.FindStringSubmatch(input)
+		return parseListCommand(&ListCommand{Archived: true}, matches[1])
+	}
+	if ListMineRegex.MatchString(input) {
+		matches := ListMineRegex.FindStringSubmatch(input)
+		return parseListCommand(&ListCommand{Mine: true}, matches[1])
+	}
+	if ListWaitingRegex.MatchString(input) {
+		matches := ListWaitingRegex.FindStringSubmatch(input)
+		return parseListCommand(&ListCommand{Waiting: true}, matches[1])
+	}
+	if ListPlayerRegex.MatchString(input) {
+		matches := ListPlayerRegex.FindStringSubmatch(input)
+		return parseListCommand(&ListCommand{Player: matches[1]}, matches[2])
+	}
+	if SetRegex.MatchString(input) {
+		matches := SetRegex.FindStringSubmatch(input)
+		return parseSetCommand(matches[1:])
+	}
+	if GameSetRegex.MatchString(input) {
+		matches := GameSetRegex.FindStringSubmatch(input)
+		return parseGameSetCommand(matches[1:])
+	}
+	if LogRegex.MatchString(input) {
+		return parseLogCommand()
+	}
+	if GameLogRegex.MatchString(input) {
+		matches := GameLogRegex.FindStringSubmatch(input)
+		return parseGameLogCommand(matches[1:])
+	}
+	if ResignRegex.MatchString(input) {
+		return parseResignCommand()
+	}
+	if GameResignRegex.MatchString(input) {
+		matches := GameResignRegex.FindStringSubmatch(input)
+		return parseGameResignCommand(matches[1:])
+	}
+	if ArchiveRegex.MatchString(input) {
+		return parseArchiveCommand()
+	}
+	if GameArchiveRegex.MatchString(input) {
+		matches := GameArchiveRegex.FindStringSubmatch(input)
+		return parseGameArchiveCommand(matches[1:])
+	}
+	if DeleteRegex.MatchString(input) {
+		matches := DeleteRegex.FindStringSubmatch(input)
+		return parseDeleteCommand(matches[1:])
+	}
+	if EstimateRegex.MatchString(input) {
+		return parseEstimateCommand()
+	}
+	if GameEstimateRegex.MatchString(input) {
+		matches := GameEstimateRegex.FindStringSubmatch(input)
+		return parseGameEstimateCommand(matches[1:])
+	}
+	if ReviewRegex.MatchString(input) {
+		return parseReviewCommand()
+	}
+	if GameReviewRegex.MatchString(input) {
+		matches := GameReviewRegex.FindStringSubmatch(input)
+		return parseGameReviewCommand(matches[1:])
+	}
+	if HintRegex.MatchString(input) {
+		matches := HintRegex.FindStringSubmatch(input)
+		return parseHintCommand(matches[1:])
+	}
+	if GameHintRegex.MatchString(input) {
+		matches := GameHintRegex.FindStringSubmatch(input)
+		return parseGameHintCommand(matches[1:])
 	}
 	return nil, fmt.Errorf("%s not understood", input)
 }
@@ -134,6 +281,31 @@ func parseStartCommand(players []string) (*StartCommand, error) {
 	}
 }
 
+func parseTimedStartCommand(args []string) (*StartCommand, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("missing vote duration")
+	}
+	duration, err := parseDuration(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &StartCommand{
+		Anyone:   true,
+		Duration: duration,
+	}, nil
+}
+
+func parseDuration(input string) (time.Duration, error) {
+	duration, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a duration like 15m or 2h", input)
+	}
+	if duration < time.Minute {
+		return 0, fmt.Errorf("vote duration must be at least a minute")
+	}
+	return duration, nil
+}
+
 func parseCoordinates(coords string) (Coords, error) {
 	// coords will be something like A12
 	result := Coords{0, 0}
@@ -253,10 +425,200 @@ func parseGameShowCommand(args []string) (*ShowCommand, error) {
 	}, nil
 }
 
-func parseListRegex() (*ListCommand, error) {
-	return &ListCommand{}, nil
+// parseListCommand reads the page of a list command, if one was given
+func parseListCommand(cmd *ListCommand, page string) (*ListCommand, error) {
+	if page == "" {
+		return cmd, nil
+	}
+	n, err := strconv.Atoi(page)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("pages start at 1")
+	}
+	cmd.Page = n
+	return cmd, nil
 }
 
-func parseListAllRegex() (*ListCommand, error) {
-	return &ListCommand{All: true}, nil
+func parseLogCommand() (*LogCommand, error) {
+	return &LogCommand{
+		Locator: Locator{Auto: true},
+	}, nil
+}
+
+func parseGameLogCommand(args []string) (*LogCommand, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("missing game id")
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &LogCommand{
+		Locator: Locator{ID: gameID},
+	}, nil
+}
+
+func parseResignCommand() (*ResignCommand, error) {
+	return &ResignCommand{
+		Locator: Locator{Auto: true},
+	}, nil
+}
+
+func parseGameResignCommand(args []string) (*ResignCommand, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("missing game id")
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &ResignCommand{
+		Locator: Locator{ID: gameID},
+	}, nil
+}
+
+func parseArchiveCommand() (*ArchiveCommand, error) {
+	return &ArchiveCommand{
+		Locator: Locator{Auto: true},
+	}, nil
+}
+
+func parseGameArchiveCommand(args []string) (*ArchiveCommand, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("missing game id")
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &ArchiveCommand{
+		Locator: Locator{ID: gameID},
+	}, nil
+}
+
+func parseDeleteCommand(args []string) (*DeleteCommand, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("missing game id")
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &DeleteCommand{
+		Locator: Locator{ID: gameID},
+	}, nil
+}
+
+func parseSetCommand(args []string) (*SetCommand, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("missing setting")
+	}
+	return parseSetting(Locator{Auto: true}, args[0], args[1])
+}
+
+func parseGameSetCommand(args []string) (*SetCommand, error) {
+	if len(args) < 3 {
+		return nil, fmt.Errorf("missing game id or setting")
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return parseSetting(Locator{ID: gameID}, args[1], args[2])
+}
+
+func parseSetting(loc Locator, key, value string) (*SetCommand, error) {
+	cmd := &SetCommand{Locator: loc, Setting: key}
+	switch key {
+	case "vote-duration":
+		duration, err := parseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Duration = duration
+	case "vote-window":
+		if value == "off" {
+			return cmd, nil
+		}
+		// e.g. 09:00-17:00 America/New_York
+		fields := strings.Fields(value)
+		if len(fields) < 1 || len(fields) > 2 {
+			return nil, fmt.Errorf("window must look like 09:00-17:00 [zone]")
+		}
+		clocks := strings.Split(fields[0], "-")
+		if len(clocks) != 2 {
+			return nil, fmt.Errorf("window must look like 09:00-17:00 [zone]")
+		}
+		zone := ""
+		if len(fields) == 2 {
+			zone = fields[1]
+		}
+		window, err := NewWindow(clocks[0], clocks[1], zone)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Window = window
+	}
+	return cmd, nil
+}
+
+func parseHintCommand(args []string) (*HintCommand, error) {
+	return &HintCommand{
+		Locator: Locator{Auto: true},
+		Private: len(args) > 0 && args[0] != "",
+	}, nil
+}
+
+func parseGameHintCommand(args []string) (*HintCommand, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("missing game id")
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &HintCommand{
+		Locator: Locator{ID: gameID},
+		Private: len(args) > 1 && args[1] != "",
+	}, nil
+}
+
+func parseReviewCommand() (*ReviewCommand, error) {
+	return &ReviewCommand{
+		Locator: Locator{Auto: true},
+	}, nil
+}
+
+func parseGameReviewCommand(args []string) (*ReviewCommand, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("missing game id")
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &ReviewCommand{
+		Locator: Locator{ID: gameID},
+	}, nil
+}
+
+func parseEstimateCommand() (*EstimateCommand, error) {
+	return &EstimateCommand{
+		Locator: Locator{Auto: true},
+	}, nil
+}
+
+func parseGameEstimateCommand(args []string) (*EstimateCommand, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("missing game id")
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &EstimateCommand{
+		Locator: Locator{ID: gameID},
+	}, nil
 }
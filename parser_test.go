@@ -3,6 +3,7 @@ package gobot_test
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	. "github.com/crestonbunch/gobot"
 )
@@ -38,6 +39,20 @@ func TestParseStartCommand(t *testing.T) {
 				White:  []string{"USER2"},
 				Anyone: false,
 			},
+		}, {
+			input: "start every 15m",
+			command: &StartCommand{
+				Anyone:   true,
+				Duration: 15 * time.Minute,
+			},
+		}, {
+			input:   "start every often",
+			command: nil,
+			err:     true,
+		}, {
+			input:   "start every 1s",
+			command: nil,
+			err:     true,
 		}, {
 			input:   "start user1 user2 user3",
 			command: nil,
@@ -98,6 +113,16 @@ func TestParseMoveCommand(t *testing.T) {
 				Move:    &Move{Pass: true},
 				Locator: Locator{ID: 12},
 			},
+		}, {
+			input: "resign",
+			command: &ResignCommand{
+				Locator: Locator{Auto: true},
+			},
+		}, {
+			input: "resign 12",
+			command: &ResignCommand{
+				Locator: Locator{ID: 12},
+			},
 		}, {
 			input:   "move 12 Z14",
 			command: nil,
@@ -284,6 +309,368 @@ func TestParseListCommand(t *testing.T) {
 		}, {
 			input:   "list all",
 			command: &ListCommand{All: true},
+		}, {
+			input:   "list archived",
+			command: &ListCommand{Archived: true},
+		}, {
+			input:   "list all 3",
+			command: &ListCommand{All: true, Page: 3},
+		}, {
+			input: "list all 0",
+			err:   true,
+		}, {
+			input:   "list mine",
+			command: &ListCommand{Mine: true},
+		}, {
+			input:   "list waiting",
+			command: &ListCommand{Waiting: true},
+		}, {
+			input:   "list U123",
+			command: &ListCommand{Player: "U123"},
+		}, {
+			input:   "list @U123",
+			command: &ListCommand{Player: "U123"},
+		}, {
+			input:   "list 3",
+			command: &ListCommand{Page: 3},
+		}, {
+			input:   "list archived 2",
+			command: &ListCommand{Archived: true, Page: 2},
+		}, {
+			input:   "list mine 2",
+			command: &ListCommand{Mine: true, Page: 2},
+		}, {
+			input:   "list waiting 2",
+			command: &ListCommand{Waiting: true, Page: 2},
+		}, {
+			input:   "list @U123 2",
+			command: &ListCommand{Player: "U123", Page: 2},
+		}, {
+			input: "list mine 0",
+			err:   true,
+		},
+	}
+
+	for _, test := range cases {
+		actual, err := ParseCommand(test.input)
+		if err == nil && test.err {
+			t.Errorf("expected %s to make an error", test.input)
+		} else if err != nil && !test.err {
+			t.Errorf(
+				"%s triggered unexpected error %s", test.input, err.Error(),
+			)
+		} else if actual == nil && test.command != nil {
+			t.Errorf("%s returned unexepected nil", test.input)
+		} else if actual != nil && test.command != nil {
+			if !reflect.DeepEqual(actual, test.command) {
+				t.Errorf(
+					"%s\n%#v\nbut expected\n%#v\n",
+					test.input, actual, test.command,
+				)
+			}
+		}
+	}
+}
+
+func TestParseSetCommand(t *testing.T) {
+	cases := []struct {
+		input   string
+		command *SetCommand
+		err     bool
+	}{
+		{
+			input: "set vote-duration 2h",
+			command: &SetCommand{
+				Locator:  Locator{Auto: true},
+				Setting:  "vote-duration",
+				Duration: 2 * time.Hour,
+			},
+		}, {
+			input: "set 14 vote-duration 30m",
+			command: &SetCommand{
+				Locator:  Locator{ID: 14},
+				Setting:  "vote-duration",
+				Duration: 30 * time.Minute,
+			},
+		}, {
+			input: "set 14 vote-window 09:00-17:00 America/New_York",
+			command: &SetCommand{
+				Locator: Locator{ID: 14},
+				Setting: "vote-window",
+				Window: &Window{
+					Start:    9 * time.Hour,
+					End:      17 * time.Hour,
+					Location: "America/New_York",
+				},
+			},
+		}, {
+			input: "set vote-window 22:30-06:00",
+			command: &SetCommand{
+				Locator: Locator{Auto: true},
+				Setting: "vote-window",
+				Window: &Window{
+					Start:    22*time.Hour + 30*time.Minute,
+					End:      6 * time.Hour,
+					Location: "UTC",
+				},
+			},
+		}, {
+			input: "set 14 vote-window off",
+			command: &SetCommand{
+				Locator: Locator{ID: 14},
+				Setting: "vote-window",
+			},
+		}, {
+			input:   "set 14 vote-duration soon",
+			command: nil,
+			err:     true,
+		}, {
+			input:   "set vote-window 09:00",
+			command: nil,
+			err:     true,
+		}, {
+			input:   "set vote-window 09:00-17:00 Mars/Olympus_Mons",
+			command: nil,
+			err:     true,
+		}, {
+			input:   "set 14 komi 6.5",
+			command: nil,
+			err:     true,
+		},
+	}
+
+	for _, test := range cases {
+		actual, err := ParseCommand(test.input)
+		if err == nil && test.err {
+			t.Errorf("expected %s to make an error", test.input)
+		} else if err != nil && !test.err {
+			t.Errorf(
+				"%s triggered unexpected error %s", test.input, err.Error(),
+			)
+		} else if actual == nil && test.command != nil {
+			t.Errorf("%s returned unexepected nil", test.input)
+		} else if actual != nil && test.command != nil {
+			if !reflect.DeepEqual(actual, test.command) {
+				t.Errorf(
+					"%s\n%#v\nbut expected\n%#v\n",
+					test.input, actual, test.command,
+				)
+			}
+		}
+	}
+}
+
+func TestParseLogCommand(t *testing.T) {
+	cases := []struct {
+		input   string
+		command *LogCommand
+		err     bool
+	}{
+		{
+			input: "log",
+			command: &LogCommand{
+				Locator: Locator{Auto: true},
+			},
+		}, {
+			input: "log 12",
+			command: &LogCommand{
+				Locator: Locator{ID: 12},
+			},
+		}, {
+			input: "log twelve",
+			err:   true,
+		},
+	}
+
+	for _, test := range cases {
+		actual, err := ParseCommand(test.input)
+		if err == nil && test.err {
+			t.Errorf("expected %s to make an error", test.input)
+		} else if err != nil && !test.err {
+			t.Errorf(
+				"%s triggered unexpected error %s", test.input, err.Error(),
+			)
+		} else if actual == nil && test.command != nil {
+			t.Errorf("%s returned unexepected nil", test.input)
+		} else if actual != nil && test.command != nil {
+			if !reflect.DeepEqual(actual, test.command) {
+				t.Errorf(
+					"%s\n%#v\nbut expected\n%#v\n",
+					test.input, actual, test.command,
+				)
+			}
+		}
+	}
+}
+
+func TestParseArchiveCommand(t *testing.T) {
+	cases := []struct {
+		input   string
+		command Command
+		err     bool
+	}{
+		{
+			input: "archive",
+			command: &ArchiveCommand{
+				Locator: Locator{Auto: true},
+			},
+		}, {
+			input: "archive 12",
+			command: &ArchiveCommand{
+				Locator: Locator{ID: 12},
+			},
+		}, {
+			input: "delete 12",
+			command: &DeleteCommand{
+				Locator: Locator{ID: 12},
+			},
+		}, {
+			input: "delete",
+			err:   true,
+		}, {
+			input: "archive twelve",
+			err:   true,
+		},
+	}
+
+	for _, test := range cases {
+		actual, err := ParseCommand(test.input)
+		if err == nil && test.err {
+			t.Errorf("expected %s to make an error", test.input)
+		} else if err != nil && !test.err {
+			t.Errorf(
+				"%s triggered unexpected error %s", test.input, err.Error(),
+			)
+		} else if actual == nil && test.command != nil {
+			t.Errorf("%s returned unexepected nil", test.input)
+		} else if actual != nil && test.command != nil {
+			if !reflect.DeepEqual(actual, test.command) {
+				t.Errorf(
+					"%s\n%#v\nbut expected\n%#v\n",
+					test.input, actual, test.command,
+				)
+			}
+		}
+	}
+}
+
+func TestParseHintCommand(t *testing.T) {
+	cases := []struct {
+		input   string
+		command Command
+		err     bool
+	}{
+		{
+			input: "hint",
+			command: &HintCommand{
+				Locator: Locator{Auto: true},
+			},
+		}, {
+			input: "hint private",
+			command: &HintCommand{
+				Locator: Locator{Auto: true},
+				Private: true,
+			},
+		}, {
+			input: "hint 12",
+			command: &HintCommand{
+				Locator: Locator{ID: 12},
+			},
+		}, {
+			input: "hint 12 private",
+			command: &HintCommand{
+				Locator: Locator{ID: 12},
+				Private: true,
+			},
+		}, {
+			input: "hint twelve",
+			err:   true,
+		},
+	}
+
+	for _, test := range cases {
+		actual, err := ParseCommand(test.input)
+		if err == nil && test.err {
+			t.Errorf("expected %s to make an error", test.input)
+		} else if err != nil && !test.err {
+			t.Errorf(
+				"%s triggered unexpected error %s", test.input, err.Error(),
+			)
+		} else if actual == nil && test.command != nil {
+			t.Errorf("%s returned unexepected nil", test.input)
+		} else if actual != nil && test.command != nil {
+			if !reflect.DeepEqual(actual, test.command) {
+				t.Errorf(
+					"%s\n%#v\nbut expected\n%#v\n",
+					test.input, actual, test.command,
+				)
+			}
+		}
+	}
+}
+
+func TestParseReviewCommand(t *testing.T) {
+	cases := []struct {
+		input   string
+		command Command
+		err     bool
+	}{
+		{
+			input: "review",
+			command: &ReviewCommand{
+				Locator: Locator{Auto: true},
+			},
+		}, {
+			input: "review 12",
+			command: &ReviewCommand{
+				Locator: Locator{ID: 12},
+			},
+		}, {
+			input: "review twelve",
+			err:   true,
+		},
+	}
+
+	for _, test := range cases {
+		actual, err := ParseCommand(test.input)
+		if err == nil && test.err {
+			t.Errorf("expected %s to make an error", test.input)
+		} else if err != nil && !test.err {
+			t.Errorf(
+				"%s triggered unexpected error %s", test.input, err.Error(),
+			)
+		} else if actual == nil && test.command != nil {
+			t.Errorf("%s returned unexepected nil", test.input)
+		} else if actual != nil && test.command != nil {
+			if !reflect.DeepEqual(actual, test.command) {
+				t.Errorf(
+					"%s\n%#v\nbut expected\n%#v\n",
+					test.input, actual, test.command,
+				)
+			}
+		}
+	}
+}
+
+func TestParseEstimateCommand(t *testing.T) {
+	cases := []struct {
+		input   string
+		command Command
+		err     bool
+	}{
+		{
+			input: "estimate",
+			command: &EstimateCommand{
+				Locator: Locator{Auto: true},
+			},
+		}, {
+			input: "score 12",
+			command: &EstimateCommand{
+				Locator: Locator{ID: 12},
+			},
+		}, {
+			input: "estimate twelve",
+			err:   true,
 		},
 	}
 
@@ -31,6 +31,7 @@ var InitializerPipeline = Pipeline{
 
 // MovePipeline executes the steps to make a move in a game
 var MovePipeline = Pipeline{
+	requireUnarchived,
 	requireUnfinished,
 	requireMoving,
 	requireAuth,
@@ -38,8 +39,18 @@ var MovePipeline = Pipeline{
 	handleMove,
 }
 
+// ResignPipeline executes the steps to resign a game
+var ResignPipeline = Pipeline{
+	requireUnarchived,
+	requireUnfinished,
+	requireMoving,
+	requireAuth,
+	handleResign,
+}
+
 // VotePipeline executes the steps to vote for a move in a game
 var VotePipeline = Pipeline{
+	requireUnarchived,
 	requireUnfinished,
 	requireVoting,
 	requireAuth,
@@ -49,10 +60,49 @@ var VotePipeline = Pipeline{
 
 // PlayPipeline executes the steps to pick a random vote in a game
 var PlayPipeline = Pipeline{
+	requireUnarchived,
 	handleSchedule,
 	handlePlay,
 }
 
+// SettingsPipeline checks a game's settings can be changed
+var SettingsPipeline = Pipeline{
+	requireUnarchived,
+	requireUnfinished,
+	requireVoting,
+}
+
+// EnginePipeline checks the engine can be asked to move in a game
+var EnginePipeline = Pipeline{
+	requireUnarchived,
+	requireUnfinished,
+	requireMoving,
+	requireEngineTurn,
+	handleShow,
+}
+
+// HintPipeline checks moves can be suggested in a game
+var HintPipeline = Pipeline{
+	requireUnarchived,
+	requireUnfinished,
+}
+
+// EstimatePipeline executes the steps to estimate the score of a game
+var EstimatePipeline = Pipeline{
+	handleEstimate,
+}
+
+// ReviewPipeline checks a game can be reviewed
+var ReviewPipeline = Pipeline{
+	requireFinished,
+}
+
+// ArchivePipeline executes the steps to archive a game
+var ArchivePipeline = Pipeline{
+	requireUnarchived,
+	handleArchive,
+}
+
 // ShowPipeline executes the steps to show a game
 var ShowPipeline = Pipeline{
 	handleShow,
@@ -93,17 +143,67 @@ func requireUnfinished(s *Session, player string, m *Move) (*Response, error) {
 	return nil, nil
 }
 
+func requireFinished(s *Session, player string, m *Move) (*Response, error) {
+	if !s.Game.Finished() {
+		return nil, errors.New("game is not over yet")
+	}
+	return nil, nil
+}
+
+func requireEngineTurn(s *Session, player string, m *Move) (*Response, error) {
+	if !s.Playable.CanMove(EngineID) {
+		return nil, errors.New("it is not the engine's turn")
+	}
+	return nil, nil
+}
+
+func requireUnarchived(s *Session, player string, m *Move) (*Response, error) {
+	if s.Game.Archived() {
+		return nil, errors.New("game is archived")
+	}
+	return nil, nil
+}
+
 func handleSchedule(s *Session, player string, m *Move) (*Response, error) {
 	s.Votable.Schedule()
 	return nil, nil
 }
 
+func handleArchive(s *Session, player string, m *Move) (*Response, error) {
+	s.Game.Archive()
+	s.Storable.Record(Event{Kind: ArchivedEvent, Player: player})
+	id := s.Storable.ID()
+	return NewTextResponse(fmt.Sprintf("game %d archived", id)), nil
+}
+
 func handleMove(s *Session, player string, m *Move) (*Response, error) {
-	return NewSessionResponse(s, m.String()), s.Game.Move(m)
+	_, next := s.Game.Position()
+	details := describeMove(s.Game.Board(), m, next)
+	err := s.Game.Move(m)
+	if err != nil {
+		return nil, err
+	}
+	s.Storable.Record(NewMoveEvent(player, m))
+	return NewSessionResponse(s, details+describeEnd(s)), nil
+}
+
+func handleResign(s *Session, player string, m *Move) (*Response, error) {
+	_, next := s.Game.Position()
+	err := s.Game.Resign()
+	if err != nil {
+		return nil, err
+	}
+	s.Storable.Record(Event{Kind: ResignEvent, Player: player})
+	return NewSessionResponse(s, colorName(next)+" resigned"), nil
 }
 
 func handleVote(s *Session, player string, m *Move) (*Response, error) {
-	return NewTextResponse("thanks for voting"), s.Votable.Vote(m)
+	err := s.Votable.Vote(m)
+	if err != nil {
+		return nil, err
+	}
+	s.Storable.Record(Event{Kind: VoteEvent, Player: player, Move: m})
+	return NewTextResponse("thanks for voting"), nil
 }
 
 func handlePlay(s *Session, player string, m *Move) (*Response, error) {
@@ -118,8 +218,36 @@ func handlePlay(s *Session, player string, m *Move) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
-	details := fmt.Sprintf("voted to %s", vote.String())
-	return NewSessionResponse(s, details), s.Game.Move(vote)
+	_, next := s.Game.Position()
+	details := "voted to " + describeMove(s.Game.Board(), vote, next)
+	err = s.Game.Move(vote)
+	if err != nil {
+		return nil, err
+	}
+	s.Storable.Record(Event{Kind: ResolvedEvent, Player: player, Move: vote})
+	return NewSessionResponse(s, details+describeEnd(s)), nil
+}
+
+// describeEnd gives the result of a game a move just ended after a comma,
+// naming the stones that were taken off as dead for it, or nothing if the
+// game goes on
+func describeEnd(s *Session) string {
+	summary := s.Game.Summary()
+	if !summary.Finished {
+		return ""
+	}
+	details := ", game over " + summary.Result
+	if len(summary.Dead) > 0 {
+		details += " counting " + pointList(summary.Dead) + " as dead"
+	}
+	return details
+}
+
+func handleEstimate(s *Session, player string, m *Move) (*Response, error) {
+	e := s.Game.Board().Estimate(s.Game.Summary().Captures, Komi)
+	response := NewSessionResponse(s, e.String())
+	response.Territory = e.Owner
+	return response, nil
 }
 
 func handleShow(s *Session, player string, m *Move) (*Response, error) {
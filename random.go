@@ -0,0 +1,45 @@
+package gobot
+
+import "math/rand"
+
+// RandomEngine plays random legal moves that do not fill its own eyes and
+// passes when there are none left
+type RandomEngine struct {
+	RNG RNG
+}
+
+// GenMove implements the Engine interface
+func (e RandomEngine) GenMove(history History, next Stone) (*Move, error) {
+	g := &State{History: history, Next: next}
+	board := g.Board()
+	moves := []*Move{}
+	for y := range board {
+		for x := range board[y] {
+			m := &Move{Coords: Coords{x, y}}
+			if board.Get(x, y) != EmptyStone || board.eye(x, y, next) ||
+				!g.Validate(m) {
+				continue
+			}
+			moves = append(moves, m)
+		}
+	}
+	if len(moves) == 0 {
+		return &Move{Pass: true}, nil
+	}
+	if e.RNG == nil {
+		return moves[rand.Intn(len(moves))], nil
+	}
+	return moves[e.RNG.Intn(len(moves))], nil
+}
+
+// eye checks if an empty point is surrounded by stones of one color, which
+// the player would only hurt themself by filling
+func (b Board) eye(x, y int, stone Stone) bool {
+	for _, n := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+		neighbor := b.Get(n[0], n[1])
+		if neighbor != stone && neighbor != BoundaryStone {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package gobot
+
+import "sync"
+
+// Registry keeps track of the sessions that are loaded in memory so every
+// command and background task for a game shares one session. It is safe for
+// concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	sessions map[int64]*Session
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{sessions: map[int64]*Session{}}
+}
+
+// Get a session by id
+func (r *Registry) Get(id int64) (*Session, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sess, ok := r.sessions[id]
+	return sess, ok
+}
+
+// Add a session to the registry unless one with the same id is already
+// registered. Returns the registered session and whether it was added.
+func (r *Registry) Add(sess *Session) (*Session, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := sess.Storable.ID()
+	if existing, ok := r.sessions[id]; ok {
+		return existing, false
+	}
+	r.sessions[id] = sess
+	return sess, true
+}
+
+// Remove a session from the registry
+func (r *Registry) Remove(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+}
+
+// All returns every registered session
+func (r *Registry) All() []*Session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*Session, 0, len(r.sessions))
+	for _, sess := range r.sessions {
+		list = append(list, sess)
+	}
+	return list
+}
+
+// Len returns the number of registered sessions
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.sessions)
+}
@@ -1,6 +1,6 @@
 package gobot
 
-import "time"
+import "errors"
 
 // A Request connects a user command to a Store to perform an action.
 type Request struct {
@@ -8,16 +8,37 @@ type Request struct {
 	Command Command
 	Session *Session
 	List    []*Session
+	// Total is how many games match a list command, on every page
+	Total   int
 	Player  string
+	Channel string
+	Admin   bool
+	// Directory names players in replies, Mentions if not set
+	Directory Directory
+	// Engine plays for the engine in two player games, if there is one
+	Engine Engine
+	// Analyzer suggests moves, if there is one
+	Analyzer Analyzer
+	// Evaluator judges positions for reviews, if there is one
+	Evaluator Evaluator
+	// reviews limits the reviews that run at once
+	reviews *reviews
 }
 
 // NewRequest constructs a request from a user command and session store
-func NewRequest(cmd Command, player string, str Store) (*Request, error) {
+func NewRequest(
+	cmd Command, player, channel string, str Store,
+) (*Request, error) {
 	var list []*Session
+	var total int
 	var sess *Session
 	var err error
 	switch cmd := cmd.(type) {
 	case *StartCommand:
+		duration := cmd.Duration
+		if duration == 0 {
+			duration = DefaultVoteDuration
+		}
 		b := Blueprint{
 			Players: Players{
 				Anyone: cmd.Anyone,
@@ -25,21 +46,48 @@ func NewRequest(cmd Command, player string, str Store) (*Request, error) {
 				White:  cmd.White,
 			},
 			Voting: Voting{
-				Required: cmd.Anyone,         // require voting if anyone can play
-				Duration: 3600 * time.Second, // select a vote every hour
+				Required: cmd.Anyone, // require voting if anyone can play
+				Duration: duration,
 			},
+			Channel: channel,
+			Creator: player,
 		}
 		sess, err = str.New(b)
 	case *MoveCommand:
 		sess, err = cmd.Locator.Find(str)
+	case *ResignCommand:
+		sess, err = cmd.Locator.Find(str)
 	case *VoteCommand:
 		sess, err = cmd.Locator.Find(str)
 	case *PlayCommand:
 		sess, err = cmd.Locator.Find(str)
 	case *ShowCommand:
 		sess, err = cmd.Locator.Find(str)
+	case *SetCommand:
+		sess, err = cmd.Locator.Find(str)
+	case *LogCommand:
+		sess, err = cmd.Locator.Find(str)
+	case *ArchiveCommand:
+		sess, err = cmd.Locator.Find(str)
+	case *HintCommand:
+		sess, err = cmd.Locator.Find(str)
+	case *EstimateCommand:
+		sess, err = cmd.Locator.Find(str)
+	case *ReviewCommand:
+		if cmd.Locator.Auto {
+			// the last game is always one that is still being played
+			sess, err = str.LastFinished()
+		} else {
+			sess, err = cmd.Locator.Find(str)
+		}
+	case *DeleteCommand:
+		// loading the game would register it and start its vote loop, so
+		// only check the game is named
+		if cmd.Locator.Auto {
+			err = errors.New("say which game to delete")
+		}
 	case *ListCommand:
-		list, err = str.List(cmd.All)
+		list, total, err = str.Search(cmd.Filter(player))
 	}
 	if err != nil {
 		return nil, err
@@ -50,7 +98,9 @@ func NewRequest(cmd Command, player string, str Store) (*Request, error) {
 		Command: cmd,
 		Session: sess,
 		List:    list,
+		Total:   total,
 		Player:  player,
+		Channel: channel,
 	}, nil
 }
 
@@ -62,3 +112,11 @@ func (r *Request) Respond() (*Response, error) {
 	}
 	return nil, nil
 }
+
+// names returns the directory used to name players in replies
+func (r *Request) names() Directory {
+	if r.Directory == nil {
+		return Mentions{}
+	}
+	return r.Directory
+}
@@ -1,10 +1,35 @@
 package gobot
 
-// Response is a response to a command. It can contain text or a game state.
+import (
+	"context"
+	"image"
+)
+
+// Response is a response to a command. It can contain text, an image or a
+// game state. Session responses carry a snapshot of the game so they can be
+// rendered without holding the session lock. Channel is where the response
+// should be sent, or empty if it should go to the default channel. Private
+// responses go straight to one user instead.
 type Response struct {
-	Session *Session
-	Text    string
-	Details string
+	Session  *Session
+	Text     string
+	Details  string
+	Channel  string
+	ID       int64
+	Board    Board
+	Finished bool
+	Players  Players
+	// Markers label points on the board
+	Markers []Marker
+	// Territory shades the points each color owns, indexed like the board
+	Territory [][]Stone
+	// Private is the user to send the response to, if only they should see it
+	Private string
+	// Image is sent with Text as its title
+	Image image.Image
+	// Later is slow work to do after the response is sent, whose responses
+	// are sent when it is done. It should stop when the context is done.
+	Later func(context.Context) []*Response
 }
 
 // NewTextResponse builds a text response
@@ -14,5 +39,13 @@ func NewTextResponse(text string) *Response {
 
 // NewSessionResponse builds a session response
 func NewSessionResponse(s *Session, details string) *Response {
-	return &Response{Session: s, Details: details}
+	return &Response{
+		Session:  s,
+		Details:  details,
+		Channel:  s.Playable.Channel(),
+		ID:       s.Storable.ID(),
+		Board:    s.Game.Board().Copy(),
+		Finished: s.Game.Finished(),
+		Players:  s.Game.Summary().Players,
+	}
 }
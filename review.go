@@ -0,0 +1,347 @@
+package gobot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ReviewMistakes is how many of the worst moves a review shows
+const ReviewMistakes = 3
+
+// DefaultReviewPlayouts is how many games the built-in engine plays out to
+// judge each position of a review. Reviews look at every position, so they
+// get a smaller budget than moves do.
+const DefaultReviewPlayouts = 200
+
+// MaxReviews is how many reviews run at once. More wait for one to finish.
+const MaxReviews = 2
+
+// ChartWidth and ChartHeight are the size of the win rate chart in pixels
+const (
+	ChartWidth  = 800
+	ChartHeight = 300
+)
+
+// chartPadding is the space around the plot in the win rate chart
+const chartPadding = 20
+
+// Evaluator judges who is winning a position
+type Evaluator interface {
+	// WinRate is the chance the player to move wins, given the boards played
+	// so far
+	WinRate(history History, next Stone) (float64, error)
+}
+
+// WinRate implements the Evaluator interface
+func (e *MCTS) WinRate(history History, next Stone) (float64, error) {
+	root := e.search(history, next)
+	if root.visits == 0 {
+		return 0.5, nil
+	}
+	wins := 0.0
+	for _, child := range root.children {
+		wins += child.wins
+	}
+	return wins / float64(root.visits), nil
+}
+
+// Mistake is a move that cost the player who made it the most
+type Mistake struct {
+	// Move counts the stones played, from 1
+	Move   int
+	Player Stone
+	Coords Coords
+	// Loss is how much the move lowered the player's win rate
+	Loss float64
+	// Board is the board right after the move
+	Board Board
+}
+
+// String implements the stringer interface
+func (m Mistake) String() string {
+	return fmt.Sprintf(
+		"move %d, %s %s (-%.0f%%)", m.Move, colorName(m.Player),
+		m.Coords.String(), 100*m.Loss,
+	)
+}
+
+// Review judges every position of a game
+type Review struct {
+	ID      int64
+	Players Players
+	Channel string
+	// WinRates are black's chances of winning after each board of the game,
+	// starting with the empty board
+	WinRates []float64
+	// Mistakes are the moves that cost the most, worst first
+	Mistakes []Mistake
+}
+
+// NewReview judges each board of a game. Passes are not part of the history,
+// so whoever placed the last stone is taken to have moved last. Returns the
+// context's error if it is done before the review is.
+func NewReview(
+	ctx context.Context, id int64, history History, e Evaluator,
+) (*Review, error) {
+	r := &Review{ID: id, WinRates: make([]float64, len(history))}
+	players := make([]Stone, len(history))
+	coords := make([]Coords, len(history))
+	for i := range history {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		next := BlackStone
+		if i > 0 {
+			stone, x, y, ok := placed(history[i-1], history[i])
+			if !ok {
+				return nil, fmt.Errorf("no stone was placed in move %d", i)
+			}
+			players[i], coords[i] = stone, Coords{x, y}
+			next = stone.Opponent()
+		}
+		rate, err := e.WinRate(history[:i+1], next)
+		if err != nil {
+			return nil, err
+		}
+		if next == WhiteStone {
+			rate = 1 - rate
+		}
+		r.WinRates[i] = rate
+	}
+	for i := 1; i < len(history); i++ {
+		loss := r.WinRates[i-1] - r.WinRates[i]
+		if players[i] == WhiteStone {
+			loss = -loss
+		}
+		if loss <= 0 {
+			continue
+		}
+		r.Mistakes = append(r.Mistakes, Mistake{
+			Move:   i,
+			Player: players[i],
+			Coords: coords[i],
+			Loss:   loss,
+			Board:  history[i],
+		})
+	}
+	sort.SliceStable(r.Mistakes, func(i, j int) bool {
+		return r.Mistakes[i].Loss > r.Mistakes[j].Loss
+	})
+	if len(r.Mistakes) > ReviewMistakes {
+		r.Mistakes = r.Mistakes[:ReviewMistakes]
+	}
+	return r, nil
+}
+
+// String summarizes the review
+func (r *Review) String() string {
+	last := r.WinRates[len(r.WinRates)-1]
+	lines := []string{fmt.Sprintf(
+		"review of game %d: black's chances went from %.0f%% to %.0f%% over "+
+			"%s", r.ID, 100*r.WinRates[0], 100*last,
+		plural(len(r.WinRates)-1, "move"),
+	)}
+	if len(r.Mistakes) == 0 {
+		lines = append(lines, "no mistakes found")
+	}
+	for i, m := range r.Mistakes {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, m.String()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Responses are the summary with the win rate chart, then a board for each
+// mistake with the move marked
+func (r *Review) Responses() []*Response {
+	chart := &Response{
+		Channel: r.Channel,
+		ID:      r.ID,
+		Text:    fmt.Sprintf("Game %d win rate", r.ID),
+		Details: r.String(),
+		Image:   r.Chart(),
+	}
+	responses := []*Response{chart}
+	for i, m := range r.Mistakes {
+		responses = append(responses, &Response{
+			Channel:  r.Channel,
+			ID:       r.ID,
+			Details:  fmt.Sprintf("mistake %d: %s", i+1, m.String()),
+			Board:    m.Board,
+			Finished: true,
+			Players:  r.Players,
+			Markers:  []Marker{{Coords: m.Coords, Label: markerLabels[i]}},
+		})
+	}
+	return responses
+}
+
+// Chart draws black's win rate over the game as a line, with the even line
+// in grey and the mistakes in red
+func (r *Review) Chart() image.Image {
+	im := image.NewRGBA(image.Rect(0, 0, ChartWidth, ChartHeight))
+	draw.Draw(im, im.Bounds(), image.White, image.ZP, draw.Src)
+	grey := color.RGBA{200, 200, 200, 255}
+	red := color.RGBA{220, 40, 40, 255}
+	point := func(i int) image.Point {
+		x := chartPadding
+		if len(r.WinRates) > 1 {
+			x += i * (ChartWidth - 2*chartPadding) / (len(r.WinRates) - 1)
+		}
+		y := chartPadding +
+			int((1-r.WinRates[i])*float64(ChartHeight-2*chartPadding))
+		return image.Point{x, y}
+	}
+	drawLine(
+		im, image.Point{chartPadding, ChartHeight / 2},
+		image.Point{ChartWidth - chartPadding, ChartHeight / 2}, grey,
+	)
+	for i := 1; i < len(r.WinRates); i++ {
+		drawLine(im, point(i-1), point(i), color.Black)
+	}
+	for _, m := range r.Mistakes {
+		p := point(m.Move)
+		draw.DrawMask(
+			im, im.Bounds(), image.NewUniform(red), image.ZP,
+			&Circle{p, 5}, image.ZP, draw.Over,
+		)
+	}
+	return im
+}
+
+// drawLine draws a straight line between two points
+func drawLine(im draw.Image, from, to image.Point, c color.Color) {
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	sx, sy := 1, 1
+	if from.X > to.X {
+		sx = -1
+	}
+	if from.Y > to.Y {
+		sy = -1
+	}
+	err := dx + dy
+	for p := from; ; {
+		im.Set(p.X, p.Y, c)
+		if p == to {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			p.Y += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// reviews keeps track of the reviews in progress, so a game is only reviewed
+// once at a time and only MaxReviews run at once
+type reviews struct {
+	mu    sync.Mutex
+	games map[int64]bool
+	slots chan struct{}
+}
+
+func newReviews(n int) *reviews {
+	return &reviews{games: map[int64]bool{}, slots: make(chan struct{}, n)}
+}
+
+// running checks if a game is being reviewed
+func (r *reviews) running(id int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.games[id]
+}
+
+// start marks a game as being reviewed and waits for a free slot. Every
+// review that starts must be done.
+func (r *reviews) start(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	if r.games[id] {
+		r.mu.Unlock()
+		return fmt.Errorf("game %d is already being reviewed", id)
+	}
+	r.games[id] = true
+	r.mu.Unlock()
+	select {
+	case r.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		r.forget(id)
+		return ctx.Err()
+	}
+}
+
+// done frees the slot of a finished review
+func (r *reviews) done(id int64) {
+	<-r.slots
+	r.forget(id)
+}
+
+func (r *reviews) forget(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.games, id)
+}
+
+// review starts a review of a finished game. The review itself is slow, so
+// it is done after replying, without the session lock, once the game is not
+// being reviewed already and there is a free slot. The caller must hold the
+// session lock.
+func review(s *Session, e Evaluator, rs *reviews) (*Response, error) {
+	if e == nil {
+		return nil, errors.New("no analysis is set up")
+	}
+	id := s.Storable.ID()
+	if rs.running(id) {
+		return nil, fmt.Errorf("game %d is already being reviewed", id)
+	}
+	history, _ := s.Game.Position()
+	history = append(History{}, history...)
+	players := s.Game.Summary().Players
+	channel := s.Playable.Channel()
+	response := NewTextResponse(fmt.Sprintf(
+		"reviewing game %d, this may take a while", id,
+	))
+	response.Later = func(ctx context.Context) []*Response {
+		r, err := reviewLater(ctx, id, history, e, rs)
+		if err != nil {
+			failed := NewTextResponse(fmt.Sprintf(
+				"could not review game %d: %s", id, err.Error(),
+			))
+			failed.Channel = channel
+			return []*Response{failed}
+		}
+		r.Players, r.Channel = players, channel
+		return r.Responses()
+	}
+	return response, nil
+}
+
+// reviewLater reviews a game once it has a slot
+func reviewLater(
+	ctx context.Context, id int64, history History, e Evaluator, rs *reviews,
+) (*Review, error) {
+	err := rs.start(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.done(id)
+	return NewReview(ctx, id, history, e)
+}
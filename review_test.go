@@ -0,0 +1,175 @@
+package gobot_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+// fakeEvaluator gives the win rate of the player to move by how many boards
+// have been played
+type fakeEvaluator struct {
+	rates map[int]float64
+}
+
+func (e *fakeEvaluator) WinRate(history History, next Stone) (float64, error) {
+	rate, ok := e.rates[len(history)]
+	if !ok {
+		return 0, fmt.Errorf("no win rate for %d boards", len(history))
+	}
+	return rate, nil
+}
+
+// reviewedGame plays black D4, white P16 and black C3
+func reviewedGame(t *testing.T) History {
+	g := &State{History: History{New19by19Board()}, Next: BlackStone}
+	for _, c := range []Coords{{3, 3}, {15, 15}, {2, 2}} {
+		if err := g.Move(&Move{Coords: c}); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	return g.History
+}
+
+func TestNewReview(t *testing.T) {
+	history := reviewedGame(t)
+	// black's chances are 50%, 60%, 90% and 30%
+	e := &fakeEvaluator{rates: map[int]float64{1: 0.5, 2: 0.4, 3: 0.9, 4: 0.7}}
+	r, err := NewReview(context.Background(), 14, history, e)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	rates := []float64{0.5, 0.6, 0.9, 0.3}
+	for i, rate := range rates {
+		if diff := r.WinRates[i] - rate; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("expected black's chances after move %d to be %f but "+
+				"got %f", i, rate, r.WinRates[i])
+		}
+	}
+	expected := "review of game 14: black's chances went from 50% to 30% " +
+		"over 3 moves\n1. move 3, black C3 (-60%)\n2. move 2, white P16 (-30%)"
+	if r.String() != expected {
+		t.Errorf("expected %q but got %q", expected, r.String())
+	}
+	if !r.Mistakes[0].Board.Equals(history[3]) {
+		t.Errorf("expected the board after the worst mistake")
+	}
+
+	responses := r.Responses()
+	if len(responses) != 3 || responses[0].Image == nil {
+		t.Fatalf("expected a chart and two boards but got %v", responses)
+	}
+	markers := []Marker{{Coords: Coords{15, 15}, Label: 'B'}}
+	if !reflect.DeepEqual(responses[2].Markers, markers) {
+		t.Errorf("expected markers %v but got %v", markers, responses[2].Markers)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewReview(ctx, 14, history, e); err == nil {
+		t.Errorf("expected a cancelled review to fail")
+	}
+}
+
+func TestServerReview(t *testing.T) {
+	server := newTestServer(
+		t, "start U1 U1", "move D4", "move P16", "move C3", "move pass",
+	)
+	defer server.Close()
+	if err := server.Handle("review 1", "U1", "C1"); err == nil {
+		t.Errorf("expected reviewing an unfinished game to fail")
+	}
+	if err := server.Handle("move pass", "U1", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	if err := server.Handle("review 1", "U1", "C1"); err == nil {
+		t.Errorf("expected reviewing without analysis to fail")
+	}
+
+	server.Evaluator = &fakeEvaluator{
+		rates: map[int]float64{1: 0.5, 2: 0.4, 3: 0.9, 4: 0.7},
+	}
+	if err := server.Handle("review", "U1", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []string{
+		"reviewing game 1, this may take a while",
+		"Game 1 win rate",
+		"mistake 1: move 3, black C3 (-60%)",
+		"mistake 2: move 2, white P16 (-30%)",
+	}
+	for _, text := range expected {
+		r := <-server.Replies
+		if r.Text != text && r.Details != text {
+			t.Errorf("expected %q but got %v", text, r)
+		}
+		if r.Channel != "C1" {
+			t.Errorf("expected %q to be sent to C1 but got %s", text, r.Channel)
+		}
+	}
+}
+
+// blockingEvaluator says when it starts judging a position and waits to be
+// let go
+type blockingEvaluator struct {
+	started chan int
+	release chan struct{}
+}
+
+func (e *blockingEvaluator) WinRate(
+	history History, next Stone,
+) (float64, error) {
+	e.started <- len(history)
+	<-e.release
+	return 0.5, nil
+}
+
+func TestServerReviewLimits(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	evaluator := &blockingEvaluator{
+		started: make(chan int, 10),
+		release: make(chan struct{}),
+	}
+	server.Evaluator = evaluator
+	review := func(id int) {
+		for _, c := range []string{"start U1 U1", "move pass", "move pass"} {
+			if err := server.Handle(c, "U1", "C1"); err != nil {
+				t.Fatalf("%s: %s", c, err.Error())
+			}
+			<-server.Replies
+		}
+		if err := server.Handle(fmt.Sprintf("review %d", id), "U1",
+			"C1"); err != nil {
+			t.Fatalf(err.Error())
+		}
+		<-server.Replies
+	}
+	review(1)
+	<-evaluator.started
+	// a game is reviewed once at a time
+	if err := server.Handle("review 1", "U1", "C1"); err == nil {
+		t.Errorf("expected reviewing a game twice at once to fail")
+	}
+	for id := 2; id <= MaxReviews+1; id++ {
+		review(id)
+	}
+	for i := 1; i < MaxReviews; i++ {
+		<-evaluator.started
+	}
+	select {
+	case <-evaluator.started:
+		t.Errorf("expected at most %d reviews at once", MaxReviews)
+	case <-time.After(50 * time.Millisecond):
+	}
+	// the last review runs once the others are done
+	close(evaluator.release)
+	for i := 0; i < MaxReviews+1; i++ {
+		<-server.Replies
+	}
+}
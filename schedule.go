@@ -0,0 +1,135 @@
+package gobot
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultVoteDuration is how long votes are collected before one is picked
+const DefaultVoteDuration = time.Hour
+
+// Window restricts when votes are resolved to a time of day range in a
+// particular time zone, e.g. working hours in America/New_York. If End is
+// before Start the window wraps around midnight.
+type Window struct {
+	// Offset from midnight when the window opens
+	Start time.Duration `json:"start"`
+	// Offset from midnight when the window closes
+	End time.Duration `json:"end"`
+	// IANA time zone name, e.g. America/New_York
+	Location string `json:"location"`
+}
+
+// NewWindow creates a window from clock times like 09:00 and 17:00 in the
+// given time zone. An empty time zone means UTC.
+func NewWindow(start, end, location string) (*Window, error) {
+	from, err := parseClock(start)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseClock(end)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, fmt.Errorf("window %s-%s is empty", start, end)
+	}
+	if location == "" {
+		location = "UTC"
+	}
+	_, err = time.LoadLocation(location)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %s", location)
+	}
+	return &Window{Start: from, End: to, Location: location}, nil
+}
+
+// Contains checks if the window is open at time t
+func (w *Window) Contains(t time.Time) bool {
+	start, end := w.bounds(t)
+	local := t.In(start.Location())
+	if w.Start < w.End {
+		return !local.Before(start) && local.Before(end)
+	}
+	return !local.Before(start) || local.Before(end)
+}
+
+// Next returns t if the window is open at time t, otherwise the next time
+// the window opens.
+func (w *Window) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	start, _ := w.bounds(t)
+	if t.Before(start) {
+		return start
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// String implements the stringer interface
+func (w *Window) String() string {
+	return fmt.Sprintf(
+		"%s-%s %s", formatClock(w.Start), formatClock(w.End), w.Location,
+	)
+}
+
+// bounds returns the opening and closing times of the window on the day
+// of t in the window's time zone.
+func (w *Window) bounds(t time.Time) (time.Time, time.Time) {
+	loc, err := time.LoadLocation(w.Location)
+	if err != nil {
+		loc = time.UTC
+	}
+	year, month, day := t.In(loc).Date()
+	// build the clock times rather than adding to midnight, which would be
+	// off by an hour on days the clocks change
+	at := func(d time.Duration) time.Time {
+		hour, minute := int(d/time.Hour), int(d%time.Hour/time.Minute)
+		return time.Date(year, month, day, hour, minute, 0, 0, loc)
+	}
+	return at(w.Start), at(w.End)
+}
+
+// Next returns when the next vote should be picked if voting starts at t
+func (v Voting) Next(t time.Time) time.Time {
+	duration := v.Duration
+	if duration <= 0 {
+		duration = DefaultVoteDuration
+	}
+	next := t.Add(duration)
+	if v.Window != nil {
+		next = v.Window.Next(next)
+	}
+	return next
+}
+
+// String implements the stringer interface
+func (v Voting) String() string {
+	if !v.Required {
+		return "voting not required"
+	}
+	duration := v.Duration
+	if duration <= 0 {
+		duration = DefaultVoteDuration
+	}
+	if v.Window == nil {
+		return fmt.Sprintf("votes picked every %s", duration)
+	}
+	return fmt.Sprintf(
+		"votes picked every %s during %s", duration, v.Window.String(),
+	)
+}
+
+func parseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a time like 09:00", clock)
+	}
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute, nil
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,104 @@
+package gobot_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestWindowNext(t *testing.T) {
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	cases := []struct {
+		desc   string
+		window *Window
+		input  time.Time
+		expect time.Time
+	}{
+		{
+			desc:   "inside the window",
+			window: &Window{9 * time.Hour, 17 * time.Hour, "UTC"},
+			input:  time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC),
+			expect: time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC),
+		}, {
+			desc:   "before the window opens",
+			window: &Window{9 * time.Hour, 17 * time.Hour, "UTC"},
+			input:  time.Date(2018, 6, 1, 3, 0, 0, 0, time.UTC),
+			expect: time.Date(2018, 6, 1, 9, 0, 0, 0, time.UTC),
+		}, {
+			desc:   "after the window closes",
+			window: &Window{9 * time.Hour, 17 * time.Hour, "UTC"},
+			input:  time.Date(2018, 6, 1, 17, 0, 0, 0, time.UTC),
+			expect: time.Date(2018, 6, 2, 9, 0, 0, 0, time.UTC),
+		}, {
+			desc:   "overnight window late",
+			window: &Window{22 * time.Hour, 6 * time.Hour, "UTC"},
+			input:  time.Date(2018, 6, 1, 23, 0, 0, 0, time.UTC),
+			expect: time.Date(2018, 6, 1, 23, 0, 0, 0, time.UTC),
+		}, {
+			desc:   "overnight window early",
+			window: &Window{22 * time.Hour, 6 * time.Hour, "UTC"},
+			input:  time.Date(2018, 6, 1, 5, 0, 0, 0, time.UTC),
+			expect: time.Date(2018, 6, 1, 5, 0, 0, 0, time.UTC),
+		}, {
+			desc:   "overnight window closed",
+			window: &Window{22 * time.Hour, 6 * time.Hour, "UTC"},
+			input:  time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC),
+			expect: time.Date(2018, 6, 1, 22, 0, 0, 0, time.UTC),
+		}, {
+			desc: "other time zone",
+			window: &Window{
+				9 * time.Hour, 17 * time.Hour, "America/New_York",
+			},
+			input:  time.Date(2018, 6, 1, 7, 0, 0, 0, time.UTC),
+			expect: time.Date(2018, 6, 1, 9, 0, 0, 0, nyc),
+		}, {
+			// the clocks go forward an hour at 02:00 that morning
+			desc: "daylight saving time starting",
+			window: &Window{
+				9 * time.Hour, 17 * time.Hour, "America/New_York",
+			},
+			input:  time.Date(2018, 3, 11, 5, 0, 0, 0, time.UTC),
+			expect: time.Date(2018, 3, 11, 9, 0, 0, 0, nyc),
+		},
+	}
+	for _, test := range cases {
+		actual := test.window.Next(test.input)
+		if !actual.Equal(test.expect) {
+			t.Errorf(
+				"%s: expected %s but got %s", test.desc, test.expect, actual,
+			)
+		}
+	}
+}
+
+func TestVotingNext(t *testing.T) {
+	start := time.Date(2018, 6, 1, 16, 30, 0, 0, time.UTC)
+	cases := []struct {
+		voting Voting
+		expect time.Time
+	}{
+		{
+			voting: Voting{Duration: 15 * time.Minute},
+			expect: time.Date(2018, 6, 1, 16, 45, 0, 0, time.UTC),
+		}, {
+			voting: Voting{},
+			expect: time.Date(2018, 6, 1, 17, 30, 0, 0, time.UTC),
+		}, {
+			voting: Voting{
+				Duration: time.Hour,
+				Window:   &Window{9 * time.Hour, 17 * time.Hour, "UTC"},
+			},
+			expect: time.Date(2018, 6, 2, 9, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, test := range cases {
+		actual := test.voting.Next(start)
+		if !actual.Equal(test.expect) {
+			t.Errorf("expected %s but got %s", test.expect, actual)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package gobot
+
+import "strconv"
+
+// AreaScore counts the points of each color under area scoring: the stones
+// on the board plus the empty points that only reach stones of that color.
+// Every stone left on the board is counted as alive.
+func (b Board) AreaScore() (black, white int) {
+	visited := map[[2]int]bool{}
+	for y := range b {
+		for x := range b[y] {
+			switch b.Get(x, y) {
+			case BlackStone:
+				black++
+			case WhiteStone:
+				white++
+			case EmptyStone:
+				if visited[[2]int{x, y}] {
+					continue
+				}
+				size, owner := b.region(x, y, visited)
+				switch owner {
+				case BlackStone:
+					black += size
+				case WhiteStone:
+					white += size
+				}
+			}
+		}
+	}
+	return black, white
+}
+
+// region follows the empty points connected to (x, y) and returns how many
+// there are and the color of the stones around them, or EmptyStone if they
+// reach both colors or none
+func (b Board) region(x, y int, visited map[[2]int]bool) (int, Stone) {
+	var recurse func(int, int)
+	size := 0
+	reachesBlack, reachesWhite := false, false
+	recurse = func(rx, ry int) {
+		switch b.Get(rx, ry) {
+		case BlackStone:
+			reachesBlack = true
+			return
+		case WhiteStone:
+			reachesWhite = true
+			return
+		case BoundaryStone:
+			return
+		}
+		if visited[[2]int{rx, ry}] {
+			return
+		}
+		visited[[2]int{rx, ry}] = true
+		size++
+		recurse(rx-1, ry)
+		recurse(rx+1, ry)
+		recurse(rx, ry-1)
+		recurse(rx, ry+1)
+	}
+	recurse(x, y)
+	switch {
+	case reachesBlack && !reachesWhite:
+		return size, BlackStone
+	case reachesWhite && !reachesBlack:
+		return size, WhiteStone
+	}
+	return size, EmptyStone
+}
+
+// Result describes the score of a board under area scoring with komi for
+// white, e.g. B+3.5, W+0.5, or 0 for a draw
+func (b Board) Result(komi float64) string {
+	black, white := b.AreaScore()
+	margin := float64(black) - float64(white) - komi
+	switch {
+	case margin > 0:
+		return "B+" + formatPoints(margin)
+	case margin < 0:
+		return "W+" + formatPoints(-margin)
+	}
+	return "0"
+}
+
+func formatPoints(points float64) string {
+	return strconv.FormatFloat(points, 'f', -1, 64)
+}
+
+// territory finds who owns each empty point of the board, as owner says,
+// except the liberties and eyes of stones in seki, which belong to no one.
+// Points with stones on them are EmptyStone.
+func (b Board) territory(owner func(x, y int) Stone) [][]Stone {
+	_, neutral := b.seki()
+	territory := make([][]Stone, len(b))
+	for y := range b {
+		territory[y] = make([]Stone, len(b[y]))
+		for x := range b[y] {
+			if b.Get(x, y) == EmptyStone && !neutral[Coords{x, y}] {
+				territory[y][x] = owner(x, y)
+			}
+		}
+	}
+	return territory
+}
+
+// Seki finds the stones in seki: groups that are not unconditionally alive,
+// but live because neither player can fill the liberties they share without
+// putting their own stones in atari
+func (b Board) Seki() []Coords {
+	stones, _ := b.seki()
+	seki := []Coords{}
+	for y := range b {
+		for x := range b[y] {
+			if stones[Coords{x, y}] {
+				seki = append(seki, Coords{x, y})
+			}
+		}
+	}
+	return seki
+}
+
+// seki finds the stones in seki and the empty points they make neutral,
+// which are the liberties they share and their eyes
+func (b Board) seki() (stones, neutral map[Coords]bool) {
+	// safe are the empty points that the players who could fill them cannot
+	// approach, and shared the ones that reach both colors
+	safe, shared := map[Coords]bool{}, map[Coords]bool{}
+	for y := range b {
+		for x := range b[y] {
+			if b.Get(x, y) != EmptyStone || safe[Coords{x, y}] {
+				continue
+			}
+			region := b.flood(x, y, func(s Stone) bool { return s == EmptyStone })
+			reaches := map[Stone]bool{}
+			for _, p := range region {
+				for _, n := range adjacent(p) {
+					reaches[b.Get(n[0], n[1])] = true
+				}
+			}
+			approaching := []Stone{}
+			if reaches[WhiteStone] {
+				approaching = append(approaching, BlackStone)
+			}
+			if reaches[BlackStone] {
+				approaching = append(approaching, WhiteStone)
+			}
+			if !b.unapproachable(region, approaching) {
+				continue
+			}
+			for _, p := range region {
+				safe[p] = true
+				shared[p] = reaches[BlackStone] && reaches[WhiteStone]
+			}
+		}
+	}
+	// stones may be in seki if every liberty they have is safe and some are
+	// shared, and are in seki if the stones they share them with may be too
+	alive := map[Stone]map[Coords]bool{
+		BlackStone: b.benson(BlackStone).alive,
+		WhiteStone: b.benson(WhiteStone).alive,
+	}
+	candidates := map[Coords]bool{}
+	for _, group := range b.groups() {
+		c := group[0]
+		if alive[b.Get(c[0], c[1])][c] {
+			continue
+		}
+		liberties := b.libertyPoints(c[0], c[1])
+		sharing := false
+		for _, l := range liberties {
+			if !safe[l] {
+				sharing = false
+				break
+			}
+			sharing = sharing || shared[l]
+		}
+		if sharing {
+			for _, p := range group {
+				candidates[p] = true
+			}
+		}
+	}
+	stones, neutral = map[Coords]bool{}, map[Coords]bool{}
+	for c := range shared {
+		if !shared[c] || neutral[c] {
+			continue
+		}
+		region := b.flood(c[0], c[1], func(s Stone) bool { return s == EmptyStone })
+		seki := true
+		for _, p := range region {
+			for _, n := range adjacent(p) {
+				stone := b.Get(n[0], n[1])
+				if (stone == BlackStone || stone == WhiteStone) && !candidates[n] {
+					seki = false
+				}
+			}
+		}
+		if !seki {
+			continue
+		}
+		for _, p := range region {
+			neutral[p] = true
+			for _, n := range adjacent(p) {
+				if candidates[n] {
+					for s := range b.group(n[0], n[1]) {
+						stones[s] = true
+					}
+				}
+			}
+		}
+	}
+	// the eyes of stones in seki are neutral too
+	for c := range stones {
+		for _, l := range b.libertyPoints(c[0], c[1]) {
+			neutral[l] = true
+		}
+	}
+	return stones, neutral
+}
+
+// unapproachable checks if no point of a region can be played by the given
+// colors without capturing nothing and leaving the stone played in atari
+func (b Board) unapproachable(region []Coords, colors []Stone) bool {
+	for _, p := range region {
+		for _, stone := range colors {
+			after, captures, err := b.Play(p[0], p[1], stone)
+			if err == nil && (captures > 0 || after.Liberties(p[0], p[1]) > 1) {
+				return false
+			}
+		}
+	}
+	return true
+}
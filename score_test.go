@@ -0,0 +1,103 @@
+package gobot_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+// compactBoard reads a board in the compact JSON format, e.g. "B./.W"
+func compactBoard(t *testing.T, compact string) Board {
+	var b Board
+	data, _ := json.Marshal(compact)
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatalf(err.Error())
+	}
+	return b
+}
+
+func TestAreaScore(t *testing.T) {
+	cases := []struct {
+		board  string
+		black  int
+		white  int
+		result string
+	}{
+		{"..../..../..../....", 0, 0, "W+6.5"},
+		{"B.../..../..../....", 16, 0, "B+9.5"},
+		// points that reach both colors belong to nobody
+		{".B.W/.B.W/.B.W/.B.W", 8, 4, "W+2.5"},
+		{"BB../B..W/..WW/....", 3, 3, "W+6.5"},
+		{".BW./.BW./.BW./.BW.", 8, 8, "W+6.5"},
+		{"BBB./BBB./BBBW/BBBW", 12, 2, "B+3.5"},
+		// stones in seki still count
+		{sekis[0].board, 16, 17, "W+7.5"},
+		{sekis[1].board, 16, 16, "W+6.5"},
+	}
+	for _, test := range cases {
+		b := compactBoard(t, test.board)
+		black, white := b.AreaScore()
+		if black != test.black || white != test.white {
+			t.Errorf("expected %s to score B %d W %d but got B %d W %d",
+				test.board, test.black, test.white, black, white)
+		}
+		if result := b.Result(Komi); result != test.result {
+			t.Errorf("expected %s to be %s but got %s", test.board, test.result,
+				result)
+		}
+	}
+	if result := compactBoard(t, "B.W/B.W/B.W").Result(0); result != "0" {
+		t.Errorf("expected a draw but got %s", result)
+	}
+}
+
+// sekis are common seki shapes between two outer groups that are alive
+var sekis = []struct {
+	name  string
+	board string
+	seki  []Coords
+}{
+	{
+		name:  "two shared liberties and no eyes",
+		board: ".BW.BW./BBWWBWW/.BW.BW./BBWWBWW/.BBBWW.",
+		seki: []Coords{
+			{2, 0}, {4, 0}, {2, 1}, {3, 1}, {4, 1}, {2, 2}, {4, 2}, {2, 3},
+			{3, 3}, {4, 3},
+		},
+	}, {
+		name:  "an eye each and a shared liberty",
+		board: ".BW.W.B.BW./BBWWWWBBBWW/.BBBBBWWWW.",
+		seki: []Coords{
+			{2, 0}, {4, 0}, {6, 0}, {8, 0}, {2, 1}, {3, 1}, {4, 1}, {5, 1},
+			{6, 1}, {7, 1}, {8, 1},
+		},
+	},
+}
+
+func TestSeki(t *testing.T) {
+	cases := append(sekis[:len(sekis):len(sekis)], []struct {
+		name  string
+		board string
+		seki  []Coords
+	}{
+		{
+			// black can fill the liberty at E3 without being in atari
+			name:  "a capturing race",
+			board: ".BW.BW./BBWWBWW/.BW..W./BBWWBWW/.BBBWW.",
+			seki:  []Coords{},
+		}, {
+			name:  "groups that are alive",
+			board: ".BW.W/BBWWW/.BW../BBWWW/.BW.W",
+			seki:  []Coords{},
+		},
+	}...)
+	for _, test := range cases {
+		seki := compactBoard(t, test.board).Seki()
+		if !reflect.DeepEqual(seki, test.seki) {
+			t.Errorf("%s: expected seki %v but got %v", test.name, test.seki,
+				seki)
+		}
+	}
+}
@@ -1,28 +1,93 @@
 package gobot
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"sync"
+	"time"
 )
 
+// SaveAttempts is how many times a command is tried when saving conflicts
+// with a change made by someone else
+const SaveAttempts = 3
+
+// ReplyBuffer is how many replies can be queued before commands block
+const ReplyBuffer = 64
+
 // Server handles receiving requests and performing actions.
 type Server struct {
 	Store    Store
-	Sessions map[int64]*Session
+	Sessions *Registry
 	Replies  chan *Response
-	logger   *log.Logger
+	Admins   []string
+	// Directory names players in replies
+	Directory Directory
+	// Engine plays games started with the engine as a player, if set
+	Engine Engine
+	// Voter votes in vote games nobody else voted in, if set
+	Voter Engine
+	// Analyzer suggests moves for hints, if set
+	Analyzer Analyzer
+	// Evaluator judges positions for reviews, if set
+	Evaluator Evaluator
+	reviews   *reviews
+	logger    *log.Logger
+	ctx       context.Context
+	cancel    context.CancelFunc
+	// tracks commands in flight so shutdown can wait for them
+	mu       sync.Mutex
+	closing  bool
+	inflight sync.WaitGroup
+	closed   sync.Once
+	closeErr error
 }
 
-// NewServer creates a new gobot server to listen to messages
+// NewServer creates a new gobot server to listen to messages. Votes are
+// picked with a random seed that is logged so games can be replayed.
 func NewServer(db *sql.DB) (*Server, error) {
-	responses := make(chan *Response)
+	return NewSeededServer(db, time.Now().UnixNano())
+}
+
+// NewSeededServer creates a server that picks votes using the given seed
+func NewSeededServer(db *sql.DB, seed int64) (*Server, error) {
+	return NewSeededStoreServer(NewGameStore(db), seed), nil
+}
+
+// NewSeededStoreServer creates a server that keeps games in the given store
+// and picks votes using the given seed
+func NewSeededStoreServer(store Store, seed int64) *Server {
+	store.Use(SystemClock{}, NewRNG(seed))
+	s := NewStoreServer(store)
+	s.logger.Printf("random seed %d", seed)
+	return s
+}
+
+// NewServerWithClock creates a server that uses the given clock for vote
+// timers and the given random source to pick votes.
+func NewServerWithClock(db *sql.DB, c Clock, r RNG) (*Server, error) {
+	store := NewGameStore(db)
+	store.Use(c, r)
+	return NewStoreServer(store), nil
+}
+
+// NewStoreServer creates a server that keeps games in the given store
+func NewStoreServer(store Store) *Server {
+	responses := make(chan *Response, ReplyBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Server{
-		Store:    NewGameStore(db),
-		Sessions: map[int64]*Session{},
-		Replies:  responses,
-		logger:   log.New(os.Stdout, "bot: ", log.Lshortfile),
-	}, nil
+		Store:     store,
+		Sessions:  NewRegistry(),
+		Replies:   responses,
+		Directory: Mentions{},
+		reviews:   newReviews(MaxReviews),
+		logger:    log.New(os.Stdout, "bot: ", log.Lshortfile),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
 }
 
 // Start starts the bot server
@@ -30,31 +95,175 @@ func (s *Server) Start() error {
 	return s.Load()
 }
 
-// Handle a command and return a response
-func (s *Server) Handle(input, player string) error {
+// Handle a command sent by a player in a channel and queue a response
+func (s *Server) Handle(input, player, channel string) error {
+	if !s.enter() {
+		return errors.New("gobot is shutting down")
+	}
+	defer s.inflight.Done()
 	s.logger.Printf("handling %s", input)
 	cmd, err := ParseCommand(input)
 	if err != nil {
 		return err
 	}
-	req, err := NewRequest(cmd, player, s)
+	if start, ok := cmd.(*StartCommand); ok && start.Engine() &&
+		s.Engine == nil {
+		return errors.New("no engine is set up to play against")
+	}
+	req, err := NewRequest(cmd, player, channel, s)
 	if err != nil {
 		return err
 	}
-	response, err := cmd.Execute(req)
+	req.Admin = s.IsAdmin(player)
+	req.Directory = s.Directory
+	req.Engine = s.Engine
+	req.Analyzer = s.Analyzer
+	req.Evaluator = s.Evaluator
+	req.reviews = s.reviews
+	response, err := s.execute(req)
 	if err != nil {
 		return err
 	}
-	s.Replies <- response
 	if req.Session != nil {
-		return s.Save(req.Session.Storable)
+		s.supervise(req.Session)
+	}
+	if response.Channel == "" {
+		response.Channel = channel
+	}
+	s.Replies <- response
+	if response.Later != nil {
+		s.inflight.Add(1)
+		go s.later(response.Later, channel)
 	}
 	return nil
 }
 
-// Close implements the Storable interface
+// later does slow work for a command after it has been answered and sends
+// what it comes up with. The work is cancelled when the server shuts down.
+func (s *Server) later(work func(context.Context) []*Response, channel string) {
+	defer s.inflight.Done()
+	for _, response := range work(s.ctx) {
+		if response.Channel == "" {
+			response.Channel = channel
+		}
+		s.Replies <- response
+	}
+}
+
+// enter registers a command in flight unless the server is shutting down
+func (s *Server) enter() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.inflight.Add(1)
+	return true
+}
+
+// supervise starts the background vote loop of an active vote game, and
+// stops it once the game is finished, archived or no longer needs votes.
+func (s *Server) supervise(sess *Session) {
+	sess.Lock()
+	sess.Voter = s.Voter
+	active := sess.active()
+	sess.Unlock()
+	if active {
+		sess.Start(s.ctx, s, s.Replies, s.logger)
+	} else {
+		sess.Stop()
+	}
+}
+
+// execute a request while holding the lock of the session it changes. If
+// someone else changed the game in the meantime the command is retried on
+// the reloaded game.
+func (s *Server) execute(req *Request) (*Response, error) {
+	if req.Session == nil {
+		return req.Command.Execute(req)
+	}
+	for attempt := 1; ; attempt++ {
+		response, err := s.executeLocked(req)
+		if _, ok := err.(*ConflictError); ok && attempt < SaveAttempts {
+			continue
+		}
+		return response, err
+	}
+}
+
+func (s *Server) executeLocked(req *Request) (*Response, error) {
+	req.Session.Lock()
+	defer req.Session.Unlock()
+	if req.Session.deleted {
+		return nil, fmt.Errorf("game %d was deleted", req.Session.Storable.ID())
+	}
+	response, err := req.Command.Execute(req)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Session.Storable.Unsaved() {
+		// the command only looked at the game, so it keeps its update time
+		return response, nil
+	}
+	err = s.Save(req.Session.Storable)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// IsAdmin checks if a player is allowed to run admin commands
+func (s *Server) IsAdmin(player string) bool {
+	for _, admin := range s.Admins {
+		if admin == player {
+			return true
+		}
+	}
+	return false
+}
+
+// Close shuts the server down gracefully. It stops accepting commands, waits
+// for commands in flight, stops the background vote loops, saves the loaded
+// games with unsaved changes, closes the reply channel so senders can drain it, and closes the
+// store. It is safe to call more than once.
 func (s *Server) Close() error {
-	return s.Store.Close()
+	s.closed.Do(func() {
+		s.mu.Lock()
+		s.closing = true
+		s.mu.Unlock()
+		// cancel slow work for commands, e.g. reviews, before waiting on it
+		s.cancel()
+		s.inflight.Wait()
+		sessions := s.Sessions.All()
+		for _, sess := range sessions {
+			sess.Stop()
+		}
+		for _, sess := range sessions {
+			err := s.flush(sess)
+			if err != nil {
+				s.logger.Printf("error saving %d: %s", sess.Storable.ID(), err)
+				s.closeErr = err
+			}
+		}
+		close(s.Replies)
+		err := s.Store.Close()
+		if err != nil {
+			s.closeErr = err
+		}
+		s.logger.Printf("saved %d games and closed the store", len(sessions))
+	})
+	return s.closeErr
+}
+
+// flush saves a game if it has changes that were not saved, e.g. because
+// saving failed. Games that were only looked at keep their update time.
+func (s *Server) flush(sess *Session) error {
+	sess.Lock()
+	defer sess.Unlock()
+	if !sess.Storable.Unsaved() {
+		return nil
+	}
+	return s.Save(sess.Storable)
 }
 
 // Load implements the Storable interface
@@ -68,8 +277,8 @@ func (s *Server) Load() error {
 		return err
 	}
 	for _, sess := range sessions {
-		s.Sessions[sess.Storable.ID()] = sess
-		go sess.Background(s, s.Replies, s.logger)
+		sess, _ = s.Sessions.Add(sess)
+		s.supervise(sess)
 	}
 	s.logger.Printf("loaded %d games from store", len(sessions))
 	return nil
@@ -77,10 +286,17 @@ func (s *Server) Load() error {
 
 // Get implements the Storable interface
 func (s *Server) Get(id int64) (*Session, error) {
-	if sess, ok := s.Sessions[id]; ok {
+	if sess, ok := s.Sessions.Get(id); ok {
 		return sess, nil
 	}
-	return s.Store.Get(id)
+	sess, err := s.Store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	// register the session so every command for this game shares its lock
+	sess, _ = s.Sessions.Add(sess)
+	s.supervise(sess)
+	return sess, nil
 }
 
 // New implements the Storable interface
@@ -89,8 +305,8 @@ func (s *Server) New(bp Blueprint) (*Session, error) {
 	if err != nil {
 		return nil, err
 	}
-	s.Sessions[sess.Storable.ID()] = sess
-	go sess.Background(s, s.Replies, s.logger)
+	sess, _ = s.Sessions.Add(sess)
+	s.supervise(sess)
 	return sess, nil
 }
 
@@ -103,13 +319,145 @@ func (s *Server) Last() (*Session, error) {
 	return s.Get(sess.Storable.ID())
 }
 
-// Save implements the Storable interface
+// LastFinished implements the Storable interface
+func (s *Server) LastFinished() (*Session, error) {
+	sess, err := s.Store.LastFinished()
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(sess.Storable.ID())
+}
+
+// Save implements the Storable interface. If the game was changed by someone
+// else, the loaded session is replaced with the stored game and the conflict
+// is returned. The caller must hold the session lock.
 func (s *Server) Save(storable Storable) error {
 	s.logger.Printf("saving %d", storable.ID())
-	return s.Store.Save(storable)
+	err := s.Store.Save(storable)
+	if conflict, ok := err.(*ConflictError); ok {
+		s.logger.Printf("%s, reloading", conflict.Error())
+		reloadErr := s.reload(conflict.ID)
+		if reloadErr != nil {
+			return reloadErr
+		}
+	}
+	return err
+}
+
+// reload a registered session from the store. The caller must hold the
+// session lock.
+func (s *Server) reload(id int64) error {
+	sess, ok := s.Sessions.Get(id)
+	if !ok {
+		return nil
+	}
+	fresh, err := s.Store.Get(id)
+	if err != nil {
+		return err
+	}
+	sess.replace(fresh)
+	return nil
 }
 
 // List implements the Storable interface
 func (s *Server) List(all bool) ([]*Session, error) {
-	return s.Store.List(all)
+	list, err := s.Store.List(all)
+	if err != nil {
+		return nil, err
+	}
+	return s.loaded(list), nil
+}
+
+// loaded swaps the sessions in a list for the ones in memory, since they may
+// have unsaved votes
+func (s *Server) loaded(list []*Session) []*Session {
+	for i, sess := range list {
+		if loaded, ok := s.Sessions.Get(sess.Storable.ID()); ok {
+			list[i] = loaded
+		}
+	}
+	return list
+}
+
+// Search implements the Store interface
+func (s *Server) Search(f Filter) ([]*Session, int, error) {
+	list, total, err := s.Store.Search(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.loaded(list), total, nil
+}
+
+// Use implements the Store interface
+func (s *Server) Use(c Clock, r RNG) {
+	s.Store.Use(c, r)
+}
+
+// Events implements the Store interface
+func (s *Server) Events(id int64, limit int) ([]Event, error) {
+	return s.Store.Events(id, limit)
+}
+
+// Restore implements the Store interface. Loaded copies of the game are
+// forgotten so the restored game is loaded next time.
+func (s *Server) Restore(a ArchivedGame) error {
+	err := s.Store.Restore(a)
+	if err != nil {
+		return err
+	}
+	if sess, ok := s.Sessions.Get(a.ID); ok {
+		sess.Stop()
+		s.Sessions.Remove(a.ID)
+	}
+	return nil
+}
+
+// ListArchived implements the Store interface
+func (s *Server) ListArchived() ([]*Session, error) {
+	return s.Store.ListArchived()
+}
+
+// Archive implements the Store interface. The game is archived while holding
+// its session lock, the same way as the archive command, and its vote loop is
+// stopped.
+func (s *Server) Archive(id int64) error {
+	sess, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	_, err = s.execute(&Request{
+		Store:   s,
+		Command: &ArchiveCommand{Locator: Locator{ID: id}},
+		Session: sess,
+		Admin:   true,
+	})
+	if err != nil {
+		return err
+	}
+	s.supervise(sess)
+	return nil
+}
+
+// Delete implements the Store interface. A loaded copy of the game is locked
+// while the game is deleted, then marked deleted so commands waiting on it
+// fail and its vote loop is not started again, and forgotten.
+func (s *Server) Delete(id int64) error {
+	sess, ok := s.Sessions.Get(id)
+	if !ok {
+		return s.Store.Delete(id)
+	}
+	sess.Lock()
+	err := s.Store.Delete(id)
+	if err == nil {
+		sess.deleted = true
+		s.Sessions.Remove(id)
+	}
+	sess.Unlock()
+	if err != nil {
+		return err
+	}
+	// the vote loop takes the lock to play, so it is stopped once the lock
+	// is released
+	sess.Stop()
+	return nil
 }
@@ -0,0 +1,597 @@
+package gobot_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+	"github.com/crestonbunch/gobot/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+// newTestServer starts a server that keeps games in memory, with a fake clock
+// and a fixed random source, and has U1 run each of the commands in C1. Close
+// it when done.
+func newTestServer(t *testing.T, commands ...string) *Server {
+	server := NewStoreServer(NewMemoryStore(""))
+	server.Use(NewFakeClock(time.Now()), NewRNG(1))
+	if err := server.Start(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	for _, c := range commands {
+		if err := server.Handle(c, "U1", "C1"); err != nil {
+			server.Close()
+			t.Fatalf("%s: %s", c, err.Error())
+		}
+		<-server.Replies
+	}
+	return server
+}
+
+func TestServerConcurrentCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clock := NewFakeClock(time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC))
+	duel := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+		Players: Players{Black: []string{"me"}, White: []string{"me"}},
+	}
+	vote := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+		Players: Players{Anyone: true},
+		Voting:  Voting{Required: true, Duration: time.Minute},
+	}
+	duel.Use(clock, NewRNG(1))
+	vote.Use(clock, NewRNG(1))
+	duelID := mocks.NewMockStorable(ctrl)
+	duelID.EXPECT().ID().Return(int64(1)).AnyTimes()
+	duelID.EXPECT().Record(gomock.Any()).AnyTimes()
+	voteID := mocks.NewMockStorable(ctrl)
+	voteID.EXPECT().ID().Return(int64(2)).AnyTimes()
+	voteID.EXPECT().Record(gomock.Any()).AnyTimes()
+	// every command moves or votes
+	duelID.EXPECT().Unsaved().Return(true).AnyTimes()
+	voteID.EXPECT().Unsaved().Return(true).AnyTimes()
+	voteSess := NewSession(vote, vote, voteID, vote)
+
+	store := mocks.NewMockStore(ctrl)
+	store.EXPECT().Load().Return(nil)
+	store.EXPECT().List(false).Return([]*Session{voteSess}, nil)
+	store.EXPECT().Get(int64(1)).DoAndReturn(
+		func(id int64) (*Session, error) {
+			return NewSession(duel, duel, duelID, duel), nil
+		},
+	).MinTimes(1)
+	store.EXPECT().Save(gomock.Any()).Return(nil).AnyTimes()
+
+	server := NewStoreServer(store)
+	go func() {
+		for range server.Replies {
+		}
+	}()
+	if err := server.Start(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	// Rows A and C never touch, so every move has liberties on row B
+	for _, row := range []string{"A", "C"} {
+		for col := 1; col <= 19; col++ {
+			wg.Add(2)
+			move := fmt.Sprintf("move 1 %s%d", row, col)
+			vote := fmt.Sprintf("vote 2 %s%d", row, col)
+			go func() {
+				defer wg.Done()
+				errs <- server.Handle(move, "me", "C1")
+			}()
+			go func() {
+				defer wg.Done()
+				server.Handle(vote, "you", "C1")
+				clock.Advance(time.Minute)
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error %s", err.Error())
+		}
+	}
+
+	loaded, err := server.Get(1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	again, err := server.Get(1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if loaded != again {
+		t.Errorf("expected every command to share one session")
+	}
+	loaded.Lock()
+	defer loaded.Unlock()
+	if len(duel.History) != 39 {
+		t.Errorf("expected 38 moves but got %d", len(duel.History)-1)
+	}
+}
+
+func TestServerBackgroundLifecycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clock := NewFakeClock(time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC))
+	vote := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+		Players: Players{Anyone: true},
+		Voting:  Voting{Required: true, Duration: time.Minute},
+	}
+	duel := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+		Players: Players{Black: []string{"me"}, White: []string{"me"}},
+	}
+	vote.Use(clock, NewRNG(1))
+	duel.Use(clock, NewRNG(1))
+	voteID := mocks.NewMockStorable(ctrl)
+	voteID.EXPECT().ID().Return(int64(1)).AnyTimes()
+	voteID.EXPECT().Record(gomock.Any()).AnyTimes()
+	duelID := mocks.NewMockStorable(ctrl)
+	duelID.EXPECT().ID().Return(int64(2)).AnyTimes()
+	duelID.EXPECT().Record(gomock.Any()).AnyTimes()
+	voteID.EXPECT().Unsaved().Return(true).AnyTimes()
+	duelID.EXPECT().Unsaved().Return(false).AnyTimes()
+
+	store := mocks.NewMockStore(ctrl)
+	store.EXPECT().Load().Return(nil)
+	store.EXPECT().List(false).Return(
+		[]*Session{NewSession(vote, vote, voteID, vote)}, nil,
+	)
+	store.EXPECT().Get(int64(2)).Return(
+		NewSession(duel, duel, duelID, duel), nil,
+	)
+	store.EXPECT().Save(gomock.Any()).Return(nil).AnyTimes()
+	store.EXPECT().Close().Return(nil)
+
+	server := NewStoreServer(store)
+	go func() {
+		for range server.Replies {
+		}
+	}()
+	if err := server.Start(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	voteSess, _ := server.Get(1)
+	if !voteSess.Running() {
+		t.Errorf("expected vote game to run in the background")
+	}
+	duelSess, _ := server.Get(2)
+	if duelSess.Running() {
+		t.Errorf("expected two player game not to run in the background")
+	}
+
+	for _, cmd := range []string{"vote 1 pass", "play 1", "vote 1 pass"} {
+		if err := server.Handle(cmd, "you", "C1"); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	if !voteSess.Running() {
+		t.Errorf("expected unfinished vote game to keep running")
+	}
+	if err := server.Handle("play 1", "you", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if voteSess.Running() {
+		t.Errorf("expected finished vote game to stop running")
+	}
+
+	if err := server.Close(); err != nil {
+		t.Errorf(err.Error())
+	}
+}
+
+func TestServerCloseStopsBackground(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	vote := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+		Players: Players{Anyone: true},
+		Voting:  Voting{Required: true, Duration: time.Minute},
+	}
+	vote.Use(NewFakeClock(time.Now()), NewRNG(1))
+	voteID := mocks.NewMockStorable(ctrl)
+	voteID.EXPECT().ID().Return(int64(1)).AnyTimes()
+	voteID.EXPECT().Record(gomock.Any()).AnyTimes()
+	// changes that were not saved are saved on close
+	voteID.EXPECT().Unsaved().Return(true)
+
+	store := mocks.NewMockStore(ctrl)
+	store.EXPECT().Load().Return(nil)
+	store.EXPECT().List(false).Return(
+		[]*Session{NewSession(vote, vote, voteID, vote)}, nil,
+	)
+	store.EXPECT().Save(voteID).Return(nil)
+	store.EXPECT().Close().Return(nil)
+
+	server := NewStoreServer(store)
+	if err := server.Start(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	sess, _ := server.Get(1)
+	if !sess.Running() {
+		t.Errorf("expected vote game to run in the background")
+	}
+	if err := server.Close(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if sess.Running() {
+		t.Errorf("expected close to stop the background loop")
+	}
+}
+
+func TestServerCloseDrainsReplies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	duel := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+		Players: Players{Black: []string{"me"}, White: []string{"me"}},
+	}
+	duelID := mocks.NewMockStorable(ctrl)
+	duelID.EXPECT().ID().Return(int64(1)).AnyTimes()
+	duelID.EXPECT().Record(gomock.Any()).AnyTimes()
+	// the move is saved, leaving nothing to save on close
+	duelID.EXPECT().Unsaved().Return(true)
+	duelID.EXPECT().Unsaved().Return(false)
+
+	store := mocks.NewMockStore(ctrl)
+	store.EXPECT().Get(int64(1)).Return(
+		NewSession(duel, duel, duelID, duel), nil,
+	)
+	store.EXPECT().Save(duelID).Return(nil)
+	store.EXPECT().Close().Return(nil)
+
+	server := NewStoreServer(store)
+	replies := make(chan int)
+	go func() {
+		count := 0
+		for r := range server.Replies {
+			if r.Channel != "C1" {
+				t.Errorf("expected reply to C1 but got %q", r.Channel)
+			}
+			count++
+		}
+		replies <- count
+	}()
+	if err := server.Handle("move 1 D4", "me", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := server.Close(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if err := server.Close(); err != nil {
+		t.Errorf("expected second close to be a no-op but got %s", err)
+	}
+	if count := <-replies; count != 1 {
+		t.Errorf("expected 1 reply but got %d", count)
+	}
+	if err := server.Handle("move 1 D5", "me", "C1"); err == nil {
+		t.Errorf("expected commands to be rejected after close")
+	}
+}
+
+func TestServerSaveConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	players := Players{Black: []string{"me"}, White: []string{"me"}}
+	stale := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+		Players: players,
+	}
+	// someone else played D4 and saved first
+	played, _, _ := New19by19Board().Play(3, 3, BlackStone)
+	fresh := &State{
+		History: History([]Board{New19by19Board(), played}),
+		Next:    WhiteStone,
+		Players: players,
+	}
+	id := mocks.NewMockStorable(ctrl)
+	id.EXPECT().ID().Return(int64(1)).AnyTimes()
+	id.EXPECT().Record(gomock.Any()).AnyTimes()
+	id.EXPECT().Unsaved().Return(true).AnyTimes()
+
+	store := mocks.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().Get(int64(1)).Return(
+			NewSession(stale, stale, id, stale), nil,
+		),
+		store.EXPECT().Save(id).Return(&ConflictError{ID: 1}),
+		store.EXPECT().Get(int64(1)).Return(
+			NewSession(fresh, fresh, id, fresh), nil,
+		),
+		store.EXPECT().Save(id).Return(nil),
+	)
+
+	server := NewStoreServer(store)
+	go func() {
+		for range server.Replies {
+		}
+	}()
+	if err := server.Handle("move 1 D5", "me", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(stale.History) != 2 {
+		t.Errorf("expected move on stale copy to be attempted")
+	}
+	if len(fresh.History) != 3 {
+		t.Errorf("expected move to be retried on the reloaded game")
+	}
+	sess, _ := server.Get(1)
+	if sess.Game != fresh {
+		t.Errorf("expected session to hold the reloaded game")
+	}
+}
+
+func TestServerSaveConflictGivesUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	state := &State{
+		History: History([]Board{New19by19Board()}),
+		Next:    BlackStone,
+		Players: Players{Black: []string{"me"}, White: []string{"me"}},
+	}
+	id := mocks.NewMockStorable(ctrl)
+	id.EXPECT().ID().Return(int64(1)).AnyTimes()
+	id.EXPECT().Record(gomock.Any()).AnyTimes()
+	id.EXPECT().Unsaved().Return(true).AnyTimes()
+
+	store := mocks.NewMockStore(ctrl)
+	store.EXPECT().Get(int64(1)).DoAndReturn(
+		func(int64) (*Session, error) {
+			copy := *state
+			return NewSession(&copy, &copy, id, &copy), nil
+		},
+	).Times(SaveAttempts + 1)
+	store.EXPECT().Save(id).Return(&ConflictError{ID: 1}).Times(SaveAttempts)
+
+	server := NewStoreServer(store)
+	err := server.Handle("move 1 D4", "me", "C1")
+	if _, ok := err.(*ConflictError); !ok {
+		t.Errorf("expected conflict to be reported but got %v", err)
+	}
+}
+
+func TestServerArchiveAndDelete(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	server.Admins = []string{"admin"}
+	go func() {
+		for range server.Replies {
+		}
+	}()
+
+	if err := server.Handle("start", "you", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	sess, _ := server.Get(1)
+	if !sess.Running() {
+		t.Errorf("expected vote game to run in the background")
+	}
+	if err := server.Handle("archive 1", "you", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if sess.Running() {
+		t.Errorf("expected archived vote game to stop running")
+	}
+	if err := server.Handle("vote 1 D4", "you", "C1"); err == nil {
+		t.Errorf("expected voting in an archived game to fail")
+	}
+	list, _ := server.List(true)
+	if len(list) != 0 {
+		t.Errorf("expected archived game not to be listed")
+	}
+
+	if err := server.Handle("delete 1", "you", "C1"); err == nil {
+		t.Errorf("expected delete by a player to fail")
+	}
+	if err := server.Handle("delete 1", "admin", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, ok := server.Sessions.Get(1); ok {
+		t.Errorf("expected deleted game to be forgotten")
+	}
+	if _, err := server.Store.Get(1); err == nil {
+		t.Errorf("expected deleted game to be removed from the store")
+	}
+
+	// deleting a game being played stops its vote loop for good
+	if err := server.Handle("start", "you", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	sess, _ = server.Get(2)
+	if err := server.Handle("delete 2", "admin", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if sess.Running() {
+		t.Errorf("expected deleted vote game to stop running")
+	}
+	sess.Start(context.Background(), server, server.Replies, nil)
+	if sess.Running() {
+		t.Errorf("expected deleted vote game not to start again")
+	}
+	if err := server.Handle("vote 2 D4", "you", "C1"); err == nil {
+		t.Errorf("expected voting in a deleted game to fail")
+	}
+}
+
+func TestServerVoter(t *testing.T) {
+	store := NewMemoryStore("")
+	clock := NewFakeClock(time.Now())
+	server := NewStoreServer(store)
+	server.Use(clock, NewRNG(1))
+	server.Voter = &fakeEngine{moves: []*Move{{Coords: Coords{3, 3}}}}
+	if err := server.Start(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer server.Close()
+	if err := server.Handle("start", "you", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	// advance until the vote timer fires
+	waitForVote := func() {
+		for i := 0; i < 100; i++ {
+			clock.Advance(time.Hour)
+			select {
+			case <-server.Replies:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		t.Fatalf("expected a vote to be picked")
+	}
+
+	// nobody voted, so the engine does
+	waitForVote()
+	events, _ := store.Events(1, 2)
+	if len(events) != 2 || events[0].Kind != VoteEvent ||
+		events[0].Player != EngineID || events[1].Kind != ResolvedEvent {
+		t.Fatalf("expected the engine to vote but got %v", events)
+	}
+	// someone voted, so the engine does not
+	if err := server.Handle("vote 1 E5", "you", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	waitForVote()
+	events, _ = store.Events(1, 2)
+	if len(events) != 2 || events[0].Player != "you" {
+		t.Errorf("expected only your vote but got %v", events)
+	}
+}
+
+func TestServerPlayWithoutVotes(t *testing.T) {
+	server := newTestServer(t, "start", "start U1 U2")
+	defer server.Close()
+	for _, input := range []string{"play 1", "play 2"} {
+		if err := server.Handle(input, "U1", "C1"); err != nil {
+			t.Fatalf("%s: %s", input, err.Error())
+		}
+		expected := "no votes to pick in game " + input[len(input)-1:]
+		if r := <-server.Replies; r.Text != expected {
+			t.Errorf("%s: expected %q but got %q", input, expected, r.Text)
+		}
+	}
+}
+
+func TestServerCloseKeepsUpdateTimes(t *testing.T) {
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	store := NewMemoryStore("")
+	server := NewStoreServer(store)
+	server.Use(clock, NewRNG(1))
+	if err := server.Start(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := server.Handle("start U1 U2", "U1", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	clock.Advance(48 * time.Hour)
+	// looking at a game does not change it
+	if err := server.Handle("show 1", "U1", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	if err := server.Close(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	sess, err := store.Get(1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if updated := sess.Storable.Metadata().UpdatedAt; !updated.Equal(start) {
+		t.Errorf("expected the game to be updated at %s but got %s", start,
+			updated)
+	}
+}
+
+// thinkingEngine says when it starts thinking and waits to be let go
+type thinkingEngine struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *thinkingEngine) GenMove(history History, next Stone) (*Move, error) {
+	e.started <- struct{}{}
+	<-e.release
+	return &Move{Coords: Coords{3, 3}}, nil
+}
+
+func TestServerVoterThinksUnlocked(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	server := NewStoreServer(NewMemoryStore(""))
+	server.Use(clock, NewRNG(1))
+	voter := &thinkingEngine{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	server.Voter = voter
+	if err := server.Start(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer server.Close()
+	if err := server.Handle("start", "you", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	thinking := false
+	for i := 0; i < 100 && !thinking; i++ {
+		clock.Advance(time.Hour)
+		select {
+		case <-voter.started:
+			thinking = true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if !thinking {
+		t.Fatalf("expected the voter to think about a vote")
+	}
+	// the game can be used while the voter is thinking
+	if err := server.Handle("show 1", "you", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	<-server.Replies
+	close(voter.release)
+	if r := <-server.Replies; r.Details != "voted to move at D4" {
+		t.Errorf("expected the vote to be played but got %q %q", r.Details,
+			r.Text)
+	}
+}
+
+func TestServerGameOver(t *testing.T) {
+	server := newTestServer(t, "start U1 U1", "move D4", "move pass")
+	defer server.Close()
+	if err := server.Handle("move pass", "U1", "C1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	details := "pass, game over B+354.5"
+	if r := <-server.Replies; r.Details != details {
+		t.Errorf("expected %q but got %q", details, r.Details)
+	}
+}
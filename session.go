@@ -1,49 +1,219 @@
 package gobot
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sync"
 )
 
 // A Session gets created from a request and a Store in order to make actions.
+// Anything that reads or changes the game must hold the session lock.
 type Session struct {
 	Game     Game
 	Playable Playable
 	Storable Storable
 	Votable  Votable
+	// Voter votes when no one else has by the time votes are picked
+	Voter Engine
+	mu    sync.Mutex
+	// deleted is set once the game is deleted from the store, guarded by mu
+	deleted bool
+	// guards the background vote loop
+	bg     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 // NewSession creates a new session
 func NewSession(g Game, p Playable, s Storable, v Votable) *Session {
-	return &Session{g, p, s, v}
+	return &Session{Game: g, Playable: p, Storable: s, Votable: v}
 }
 
-// Background runs background tasks for a session
-func (sess *Session) Background(s Store, ch chan *Response, l *log.Logger) {
+// Lock the session so no one else can change the game
+func (sess *Session) Lock() {
+	sess.mu.Lock()
+}
+
+// Unlock the session
+func (sess *Session) Unlock() {
+	sess.mu.Unlock()
+}
+
+// replace the game with another copy of it, e.g. one reloaded from the
+// store. The caller must hold the session lock.
+func (sess *Session) replace(other *Session) {
+	sess.Game = other.Game
+	sess.Playable = other.Playable
+	sess.Storable = other.Storable
+	sess.Votable = other.Votable
+}
+
+// Start the background vote loop if the game requires votes and is neither
+// finished nor archived. Does nothing if the loop is already running. Returns
+// true if a new loop was started.
+func (sess *Session) Start(
+	ctx context.Context, s Store, ch chan *Response, l *log.Logger,
+) bool {
+	sess.bg.Lock()
+	defer sess.bg.Unlock()
+	if sess.running() {
+		return false
+	}
+	sess.Lock()
+	active := sess.active()
+	sess.Unlock()
+	if !active {
+		return false
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	sess.cancel = cancel
+	sess.done = done
+	go func() {
+		defer close(done)
+		defer cancel()
+		sess.Background(ctx, s, ch, l)
+	}()
+	return true
+}
+
+// Stop the background vote loop and wait for it to exit
+func (sess *Session) Stop() {
+	sess.bg.Lock()
+	defer sess.bg.Unlock()
+	if sess.cancel == nil {
+		return
+	}
+	sess.cancel()
+	<-sess.done
+	sess.cancel = nil
+	sess.done = nil
+}
+
+// Running checks if the background vote loop is running
+func (sess *Session) Running() bool {
+	sess.bg.Lock()
+	defer sess.bg.Unlock()
+	return sess.running()
+}
+
+func (sess *Session) running() bool {
+	if sess.done == nil {
+		return false
+	}
+	select {
+	case <-sess.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Background picks a vote every time the vote timer fires until the game
+// is finished or archived, or the context is cancelled.
+func (sess *Session) Background(
+	ctx context.Context, s Store, ch chan *Response, l *log.Logger,
+) {
 	for {
-		l.Printf("scheduling vote for %d", sess.Storable.ID())
-		sess.Votable.Schedule()
-		sess.Votable.Block()
-		if sess.Votable.Empty() {
-			continue
+		sess.Lock()
+		if sess.Game.Finished() {
+			sess.Unlock()
+			l.Printf("game %d finished, stopping votes", sess.Storable.ID())
+			return
 		}
-		move, err := sess.Votable.Random()
-		if err != nil {
-			ch <- NewTextResponse(err.Error())
-			continue
+		if sess.Game.Archived() {
+			sess.Unlock()
+			l.Printf("game %d archived, stopping votes", sess.Storable.ID())
+			return
 		}
-		err = sess.Votable.Reset()
-		if err != nil {
-			ch <- NewTextResponse(err.Error())
-			continue
+		l.Printf("scheduling vote for %d", sess.Storable.ID())
+		timer := sess.Votable.Schedule()
+		sess.Unlock()
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.Chan():
 		}
-		err = sess.Game.Move(move)
-		if err != nil {
-			ch <- NewTextResponse(err.Error())
+		response := sess.play(s)
+		if response == nil {
 			continue
 		}
-		s.Save(sess.Storable)
-		details := fmt.Sprintf("voted to %s", move.String())
-		ch <- NewSessionResponse(sess, details)
+		select {
+		case <-ctx.Done():
+			return
+		case ch <- response:
+		}
+	}
+}
+
+// play a random vote while holding the session lock. The voter votes first
+// if no one else has, without the lock.
+func (sess *Session) play(s Store) *Response {
+	err := sess.vote()
+	if err != nil {
+		return NewTextResponse(fmt.Sprintf(
+			"engine could not vote (%s)", err.Error(),
+		))
+	}
+	sess.Lock()
+	defer sess.Unlock()
+	if sess.Game.Archived() || sess.deleted {
+		// archived or deleted while waiting for the timer
+		return nil
+	}
+	response, err := handlePlay(sess, "", nil)
+	if err != nil {
+		return NewTextResponse(err.Error())
+	}
+	if response == nil {
+		return nil
 	}
+	err = s.Save(sess.Storable)
+	if err != nil {
+		return NewTextResponse(err.Error())
+	}
+	return response
+}
+
+// vote for the move the voter picks if no one else has voted. The voter
+// thinks without the session lock, so the game can be used meanwhile, and its
+// vote is dropped if the game moved on or someone voted in the meantime.
+func (sess *Session) vote() error {
+	sess.Lock()
+	voter := sess.Voter
+	if !sess.votable() || voter == nil {
+		sess.Unlock()
+		return nil
+	}
+	history, next := sess.Game.Position()
+	history = append(History{}, history...)
+	sess.Unlock()
+	m, err := voter.GenMove(history, next)
+	if err != nil {
+		return err
+	}
+	sess.Lock()
+	defer sess.Unlock()
+	current, now := sess.Game.Position()
+	if !sess.votable() || len(current) != len(history) || now != next {
+		return nil
+	}
+	_, err = VotePipeline.Run(sess, EngineID, m)
+	return err
+}
+
+// votable checks if the voter should vote because no one else has. The
+// caller must hold the session lock.
+func (sess *Session) votable() bool {
+	return sess.Votable.Empty() && !sess.Game.Finished() &&
+		!sess.Game.Archived() && !sess.deleted
+}
+
+// active checks if the game needs its background vote loop. The caller must
+// hold the session lock.
+func (sess *Session) active() bool {
+	return sess.Votable.Required() && !sess.Game.Finished() &&
+		!sess.Game.Archived() && !sess.deleted
 }
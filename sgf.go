@@ -0,0 +1,73 @@
+package gobot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SGF writes the game in the Smart Game Format so it can be opened by other
+// go programs, naming players with a directory. The history only keeps
+// boards, so passes in the middle of a game show up as a player moving twice
+// in a row.
+func (g *State) SGF(d Directory) string {
+	var b strings.Builder
+	b.WriteString("(;GM[1]FF[4]CA[UTF-8]AP[gobot]")
+	fmt.Fprintf(&b, "SZ[%d]", len(g.History[0]))
+	fmt.Fprintf(&b, "GN[%s]", sgfEscape(fmt.Sprintf("game %d", g.id)))
+	if !g.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "DT[%s]", g.CreatedAt.UTC().Format("2006-01-02"))
+	}
+	if len(g.Players.Black) > 0 {
+		black := strings.Join(names(d, g.Players.Black), ", ")
+		fmt.Fprintf(&b, "PB[%s]", sgfEscape(black))
+	}
+	if len(g.Players.White) > 0 {
+		white := strings.Join(names(d, g.Players.White), ", ")
+		fmt.Fprintf(&b, "PW[%s]", sgfEscape(white))
+	}
+	for i := 1; i < len(g.History); i++ {
+		stone, x, y, ok := placed(g.History[i-1], g.History[i])
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, ";%s[%c%c]", sgfColor(stone), 'a'+x, 'a'+y)
+	}
+	// the last passes are known from the pass flags
+	passes := []Stone{}
+	if g.Passes.Black && g.Passes.White {
+		passes = append(passes, g.Next, g.Next.Opponent())
+	} else if g.Passes.Black || g.Passes.White {
+		passes = append(passes, g.Next.Opponent())
+	}
+	for _, stone := range passes {
+		fmt.Fprintf(&b, ";%s[]", sgfColor(stone))
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// placed finds the stone that was played between two boards
+func placed(before, after Board) (Stone, int, int, bool) {
+	for y := range after {
+		for x := range after[y] {
+			stone := after.Get(x, y)
+			if stone != EmptyStone && before.Get(x, y) == EmptyStone {
+				return stone, x, y, true
+			}
+		}
+	}
+	return EmptyStone, 0, 0, false
+}
+
+func sgfColor(s Stone) string {
+	if s == WhiteStone {
+		return "W"
+	}
+	return "B"
+}
+
+// sgfEscape escapes the characters that end or escape an SGF property value
+func sgfEscape(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return strings.Replace(s, "]", `\]`, -1)
+}
@@ -0,0 +1,56 @@
+package gobot_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestSGF(t *testing.T) {
+	cases := []struct {
+		moves    []*Move
+		expected string
+	}{
+		{
+			moves: []*Move{},
+			expected: "(;GM[1]FF[4]CA[UTF-8]AP[gobot]SZ[19]GN[game 1]" +
+				"DT[2017-01-02]PB[U1]PW[U\\]2])\n",
+		}, {
+			moves: []*Move{
+				{Coords: Coords{3, 3}},
+				{Coords: Coords{15, 16}},
+				{Pass: true},
+				{Coords: Coords{0, 0}},
+			},
+			expected: "(;GM[1]FF[4]CA[UTF-8]AP[gobot]SZ[19]GN[game 1]" +
+				"DT[2017-01-02]PB[U1]PW[U\\]2];B[dd];W[pq];W[aa])\n",
+		}, {
+			moves: []*Move{
+				{Coords: Coords{3, 3}},
+				{Pass: true},
+				{Pass: true},
+			},
+			expected: "(;GM[1]FF[4]CA[UTF-8]AP[gobot]SZ[19]GN[game 1]" +
+				"DT[2017-01-02]PB[U1]PW[U\\]2];B[dd];W[];B[])\n",
+		},
+	}
+	for _, test := range cases {
+		store := NewMemoryStore("")
+		store.Use(
+			NewFakeClock(time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)), nil,
+		)
+		sess, _ := store.New(Blueprint{
+			Players: Players{Black: []string{"U1"}, White: []string{"U]2"}},
+		})
+		game := sess.Game.(*State)
+		for _, m := range test.moves {
+			if err := game.Move(m); err != nil {
+				t.Fatalf(err.Error())
+			}
+		}
+		if actual := game.SGF(UserIDs{}); actual != test.expected {
+			t.Errorf("expected\n%s\nbut got\n%s", test.expected, actual)
+		}
+	}
+}
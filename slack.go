@@ -5,21 +5,27 @@ import (
 	"image"
 	"image/png"
 	"io/ioutil"
+	"log"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/nlopes/slack"
 )
 
 // SlackInterface controls the bot through a slack channel
 type SlackInterface struct {
-	BotID   string
-	Channel string
-	API     *slack.Client
-	RTM     *slack.RTM
-	Command chan string
-	Stop    chan bool
+	BotID      string
+	API        *slack.Client
+	RTM        *slack.RTM
+	Command    chan string
+	Stop       chan bool
+	Dispatcher *Dispatcher
+	Names      *SlackDirectory
+	stopped    sync.Once
+	mu         sync.Mutex
+	channel    string
 }
 
 // NewSlackInterface connects to slack and sets up an interface.
@@ -30,17 +36,26 @@ func NewSlackInterface(api *slack.Client) (*SlackInterface, error) {
 	}
 	rtm := api.NewRTM()
 
-	return &SlackInterface{
+	i := &SlackInterface{
 		BotID:   identity.UserID,
 		API:     api,
 		RTM:     rtm,
 		Command: make(chan string),
 		Stop:    make(chan bool),
-	}, nil
+		Names:   NewSlackDirectory(api),
+	}
+	logger := log.New(os.Stdout, "slack: ", log.Lshortfile)
+	i.Dispatcher = NewDispatcher(i, SystemClock{}, logger)
+	return i, nil
 }
 
-// Close go channels and open connections
+// Close go channels and open connections. Commands are no longer received
+// after the interface is closed.
 func (i *SlackInterface) Close() {
+	i.stopped.Do(func() {
+		close(i.Stop)
+		i.RTM.Disconnect()
+	})
 }
 
 // Block the current goroutine until the stop signal is received
@@ -48,44 +63,87 @@ func (i *SlackInterface) Block() bool {
 	return <-i.Stop
 }
 
-func (i *SlackInterface) sendText(text string) {
+// DefaultChannel is the last channel the bot was invited to or talked to in.
+// Replies for games that do not know their channel are sent there.
+func (i *SlackInterface) DefaultChannel() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.channel
+}
+
+func (i *SlackInterface) setChannel(channel string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.channel = channel
+}
+
+// Send implements the Transport interface. Private responses are sent as a
+// direct message instead.
+func (i *SlackInterface) Send(channel string, r *Response) error {
+	if r.Private != "" {
+		_, _, im, err := i.API.OpenIMChannel(r.Private)
+		if err != nil {
+			return fmt.Errorf("could not message %s: %s", r.Private, err)
+		}
+		channel = im
+	}
+	if r.Image != nil {
+		return i.sendImage(channel, r.Image, r.Text, r.Details)
+	}
+	if r.Board != nil {
+		return i.sendGame(channel, r)
+	}
+	return i.sendText(channel, r.Text)
+}
+
+func (i *SlackInterface) sendText(channel, text string) error {
 	params := slack.PostMessageParameters{}
-	i.API.PostMessage(i.Channel, text, params)
+	_, _, err := i.API.PostMessage(channel, text, params)
+	return err
 }
 
-func (i *SlackInterface) sendImage(im image.Image, name, details string) {
-	i.sendText("please hold...")
+func (i *SlackInterface) sendImage(
+	channel string, im image.Image, name, details string,
+) error {
 	temp, err := ioutil.TempFile("", "gobot")
 	if err != nil {
-		i.sendText("could not save image")
-		return
+		return fmt.Errorf("could not save image: %s", err)
 	}
 	defer os.Remove(temp.Name())
+	defer temp.Close()
 	err = png.Encode(temp, im)
 	if err != nil {
-		i.sendText("could encode png image")
-		return
+		return fmt.Errorf("could not encode png image: %s", err)
 	}
 	file := &slack.FileUploadParameters{
 		Title:          name,
 		File:           temp.Name(),
-		Channels:       []string{i.Channel},
+		Channels:       []string{channel},
 		InitialComment: details,
 	}
 	_, err = i.API.UploadFile(*file)
 	if err != nil {
-		i.sendText(fmt.Sprintf("error uploading image %s", err.Error()))
+		return fmt.Errorf("error uploading image %s", err.Error())
 	}
+	return nil
 }
 
-func (i *SlackInterface) sendGame(id int64, g Game, details string) {
-	im, _ := Render(g.Board())
+func (i *SlackInterface) sendGame(channel string, r *Response) error {
+	im, _ := RenderOverlay(r.Board, Overlay{
+		Markers: r.Markers, Territory: r.Territory,
+	})
 	suffix := ""
-	if g.Finished() {
+	if r.Finished {
 		suffix = " (finished)"
 	}
-	name := fmt.Sprintf("Game %d%s", id, suffix)
-	i.sendImage(im, name, details)
+	name := fmt.Sprintf("Game %d%s", r.ID, suffix)
+	if !r.Players.Anyone {
+		name = fmt.Sprintf(
+			"Game %d: %s vs %s%s", r.ID, playerNames(i.Names, r.Players.Black),
+			playerNames(i.Names, r.Players.White), suffix,
+		)
+	}
+	return i.sendImage(channel, im, name, r.Details)
 }
 
 // IsSlackCommand checks if the command is for the slack bot
@@ -104,35 +162,48 @@ func (i *SlackInterface) ConvertSlackCommand(input string) string {
 	return output
 }
 
-// StartSending replies received along the reply channel
+// StartSending replies received along the reply channel. Replies are handed
+// to the dispatcher so a slow upload never blocks the bot. Returns once the
+// reply channel is closed and every queued reply has been sent.
 func (i *SlackInterface) StartSending(server *Server) {
 	for r := range server.Replies {
 		if r == nil {
 			continue
 		}
-		if r.Session != nil {
-			i.sendGame(r.Session.Storable.ID(), r.Session.Game, r.Details)
-		} else {
-			i.sendText(r.Text)
+		channel := r.Channel
+		if channel == "" {
+			channel = i.DefaultChannel()
 		}
+		i.Dispatcher.Dispatch(channel, r)
 	}
+	i.Dispatcher.Close()
 }
 
 // StartReceiving commands from the Slack client
 func (i *SlackInterface) StartReceiving(server *Server) {
 	go i.RTM.ManageConnection()
 
-	for msg := range i.RTM.IncomingEvents {
+	for {
+		var msg slack.RTMEvent
+		var ok bool
+		select {
+		case <-i.Stop:
+			return
+		case msg, ok = <-i.RTM.IncomingEvents:
+			if !ok {
+				return
+			}
+		}
 		switch ev := msg.Data.(type) {
 		case *slack.MemberJoinedChannelEvent:
-			i.Channel = ev.Channel
+			i.setChannel(ev.Channel)
 		case *slack.MessageEvent:
 			if ev.SubType == "" && i.IsSlackCommand(ev.Text) {
-				i.Channel = ev.Channel
+				i.setChannel(ev.Channel)
 				command := i.ConvertSlackCommand(ev.Text)
-				err := server.Handle(command, ev.User)
+				err := server.Handle(command, ev.User, ev.Channel)
 				if err != nil {
-					i.sendText(err.Error())
+					i.Dispatcher.Dispatch(ev.Channel, NewTextResponse(err.Error()))
 				}
 			}
 		}
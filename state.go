@@ -43,6 +43,7 @@ type Players struct {
 type Voting struct {
 	Required bool          `json:"required"`
 	Duration time.Duration `json:"duration"`
+	Window   *Window       `json:"window,omitempty"`
 }
 
 // A State stores the game state for a game, and implements the Game
@@ -57,8 +58,36 @@ type State struct {
 	Votes     []*Move   `json:"votes"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	id        int64
-	timer     *time.Timer
+	ChannelID string    `json:"channel,omitempty"`
+	// the color that resigned, if one did
+	Resigned Stone `json:"resigned,omitempty"`
+	// the result once the game is over, e.g. B+3.5 or W+R
+	Result string `json:"result,omitempty"`
+	// the stones taken off as dead for the result
+	Dead []Coords `json:"dead,omitempty"`
+	// when the game was archived, or nil if it was not
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	id         int64
+	version    int64
+	timer      Timer
+	clock      Clock
+	rng        RNG
+	pending    []Event
+}
+
+// newState creates a new game from a blueprint at the given time
+func newState(bp Blueprint, now time.Time) *State {
+	return &State{
+		History:   History([]Board{New19by19Board()}),
+		Next:      BlackStone,
+		Players:   Players(bp.Players),
+		Voting:    Voting(bp.Voting),
+		Captures:  Captures{0, 0},
+		Passes:    Passes{},
+		ChannelID: bp.Channel,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
 }
 
 // ID implements the Storable interface
@@ -68,10 +97,64 @@ func (g *State) ID() int64 {
 
 // Save implements the Storable interface
 func (g *State) Save() ([]byte, error) {
-	g.UpdatedAt = time.Now()
+	g.UpdatedAt = g.now()
 	return json.Marshal(g)
 }
 
+// Metadata implements the Storable interface
+func (g *State) Metadata() Metadata {
+	players := []string{}
+	players = append(players, g.Players.Black...)
+	players = append(players, g.Players.White...)
+	waiting := []string{}
+	if !g.Finished() && !g.Players.Anyone {
+		waiting = g.Players.Black
+		if g.Next == WhiteStone {
+			waiting = g.Players.White
+		}
+	}
+	return Metadata{
+		Finished:  g.Finished(),
+		Archived:  g.Archived(),
+		CreatedAt: g.CreatedAt,
+		UpdatedAt: g.UpdatedAt,
+		Channel:   g.ChannelID,
+		Players:   players,
+		Waiting:   waiting,
+	}
+}
+
+// Record implements the Storable interface
+func (g *State) Record(e Event) {
+	e.Game = g.id
+	if e.At.IsZero() {
+		e.At = g.now()
+	}
+	g.pending = append(g.pending, e)
+}
+
+// Unsaved implements the Storable interface
+func (g *State) Unsaved() bool {
+	return len(g.pending) > 0
+}
+
+// TakeEvents implements the Storable interface
+func (g *State) TakeEvents() []Event {
+	events := g.pending
+	g.pending = nil
+	return events
+}
+
+// Version implements the Storable interface
+func (g *State) Version() int64 {
+	return g.version
+}
+
+// SetVersion implements the Storable interface
+func (g *State) SetVersion(v int64) {
+	g.version = v
+}
+
 // Load implements the Storable interface
 func (g *State) Load(blob []byte) error {
 	return json.Unmarshal(blob, g)
@@ -105,7 +188,62 @@ func (g *State) Move(m *Move) error {
 
 // Finished implements the Game interface
 func (g *State) Finished() bool {
-	return g.Passes.White && g.Passes.Black
+	return g.Passes.White && g.Passes.Black || g.Resigned != EmptyStone
+}
+
+// Resign implements the Game interface
+func (g *State) Resign() error {
+	if g.Finished() {
+		return errors.New("game is over")
+	}
+	g.Resigned = g.Next
+	g.Result = g.result(nil)
+	return nil
+}
+
+// result scores a finished game under area scoring without the given dead
+// stones, or names the winner if a player resigned
+func (g *State) result(dead []Coords) string {
+	if g.Resigned != EmptyStone {
+		return string(stoneLetters[g.Resigned.Opponent()]) + "+R"
+	}
+	return g.Board().RemoveStones(dead).Result(Komi)
+}
+
+// Archived implements the Game interface
+func (g *State) Archived() bool {
+	return g.ArchivedAt != nil
+}
+
+// Archive implements the Game interface
+func (g *State) Archive() {
+	at := g.now()
+	g.ArchivedAt = &at
+}
+
+// Position implements the Game interface
+func (g *State) Position() (History, Stone) {
+	return g.History, g.Next
+}
+
+// Summary implements the Game interface
+func (g *State) Summary() Summary {
+	result := g.Result
+	if result == "" && g.Finished() {
+		// the game finished before results were kept
+		result = g.result(g.Board().DeadStones())
+	}
+	return Summary{
+		ID:       g.id,
+		Players:  g.Players,
+		Moves:    len(g.History) - 1,
+		Next:     g.Next,
+		Captures: g.Captures,
+		Finished: g.Finished(),
+		Result:   result,
+		Dead:     g.Dead,
+		Idle:     g.now().Sub(g.UpdatedAt),
+	}
 }
 
 // IsPlaying implements the Playable interface
@@ -113,6 +251,11 @@ func (g *State) IsPlaying(p string) bool {
 	return g.Players.Anyone || g.isPlayerWhite(p) || g.isPlayerBlack(p)
 }
 
+// Channel implements the Playable interface
+func (g *State) Channel() string {
+	return g.ChannelID
+}
+
 // CanMove implements the Playable interface
 func (g *State) CanMove(p string) bool {
 	switch g.Next {
@@ -131,26 +274,33 @@ func (g *State) Vote(m *Move) error {
 }
 
 // Schedule implements the Votable interface
-func (g *State) Schedule() *time.Timer {
+func (g *State) Schedule() Timer {
+	now := g.now()
+	wait := g.Voting.Next(now).Sub(now)
 	if g.timer != nil {
-		g.timer.Reset(g.Voting.Duration)
+		g.timer.Reset(wait)
 	} else {
-		g.timer = time.NewTimer(g.Voting.Duration)
+		g.timer = g.getClock().NewTimer(wait)
 	}
 	return g.timer
 }
 
-// Block implements the Votable interface
-func (g *State) Block() {
-	<-g.timer.C
-}
-
 // Random implements the Votable interface
 func (g *State) Random() (*Move, error) {
 	if len(g.Votes) == 0 {
 		return nil, errors.New("no votes cast")
 	}
-	roll := rand.Intn(len(g.Votes))
+	var roll int
+	if seeder, ok := g.rng.(Seeder); ok {
+		// draw from a sequence of this game's own, so the same seed picks the
+		// same votes whatever other games have drawn
+		rng := seeder.Derive(g.id, int64(len(g.History)), int64(g.Next))
+		roll = rng.Intn(len(g.Votes))
+	} else if g.rng != nil {
+		roll = g.rng.Intn(len(g.Votes))
+	} else {
+		roll = rand.Intn(len(g.Votes))
+	}
 	return g.Votes[roll], nil
 }
 
@@ -170,6 +320,37 @@ func (g *State) Required() bool {
 	return g.Voting.Required
 }
 
+// Rules implements the Votable interface
+func (g *State) Rules() Voting {
+	return g.Voting
+}
+
+// SetRules implements the Votable interface
+func (g *State) SetRules(v Voting) error {
+	if v.Duration <= 0 {
+		return errors.New("vote duration must be positive")
+	}
+	g.Voting = v
+	return nil
+}
+
+// Use implements the Votable interface
+func (g *State) Use(c Clock, r RNG) {
+	g.clock = c
+	g.rng = r
+}
+
+func (g *State) getClock() Clock {
+	if g.clock == nil {
+		return SystemClock{}
+	}
+	return g.clock
+}
+
+func (g *State) now() time.Time {
+	return g.getClock().Now()
+}
+
 func (g *State) isPlayerWhite(p string) bool {
 	if g.Players.Anyone {
 		return true
@@ -222,5 +403,11 @@ func (g *State) pass() error {
 		g.Next = BlackStone
 		g.Passes.White = true
 	}
+	if g.Finished() {
+		// the stones that look dead are only a suggestion, so they are kept
+		// with the result to show what it was counted without
+		g.Dead = g.Board().DeadStones()
+		g.Result = g.result(g.Dead)
+	}
 	return nil
 }
@@ -347,6 +347,11 @@ func TestStateFinished(t *testing.T) {
 				Passes: Passes{White: true, Black: true},
 			},
 			expect: true,
+		}, {
+			state: &State{
+				Resigned: WhiteStone,
+			},
+			expect: true,
 		},
 	}
 	for _, test := range cases {
@@ -358,6 +363,41 @@ func TestStateFinished(t *testing.T) {
 	}
 }
 
+func TestStateResultDead(t *testing.T) {
+	board := compactBoard(
+		t, "......./...B.../..BWB../.BW.WB./..BWB../...B.../.......",
+	)
+	g := &State{History: History{board}, Next: BlackStone}
+	for i := 0; i < 2; i++ {
+		if err := g.Move(&Move{Pass: true}); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	dead := []Coords{{3, 2}, {2, 3}, {4, 3}, {3, 4}}
+	sortCoords(g.Dead)
+	sortCoords(dead)
+	if !reflect.DeepEqual(g.Dead, dead) {
+		t.Errorf("expected dead stones %v but got %v", dead, g.Dead)
+	}
+	if g.Result != "B+42.5" {
+		t.Errorf("expected B+42.5 but got %s", g.Result)
+	}
+	// the stones taken off are kept with the result
+	blob, err := g.Save()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	loaded := &State{}
+	if err := loaded.Load(blob); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if summary := loaded.Summary(); !reflect.DeepEqual(summary.Dead, g.Dead) ||
+		summary.Result != g.Result {
+		t.Errorf("expected %s without %v but got %s without %v", g.Result,
+			g.Dead, summary.Result, summary.Dead)
+	}
+}
+
 func TestStateVote(t *testing.T) {
 	cases := []struct {
 		state  *State
@@ -405,23 +445,69 @@ func TestStateVote(t *testing.T) {
 }
 
 func TestStateSchedule(t *testing.T) {
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
 	cases := []struct {
 		state  *State
-		expect *time.Timer
+		wait   time.Duration
+		before time.Duration
 	}{
 		{
-			state:  &State{Voting: Voting{Duration: 0}},
-			expect: time.NewTimer(0),
+			state: &State{Voting: Voting{Duration: time.Minute}},
+			wait:  time.Minute,
+		}, {
+			state: &State{Voting: Voting{Duration: 0}},
+			wait:  DefaultVoteDuration,
+		}, {
+			state: &State{Voting: Voting{
+				Duration: time.Hour,
+				Window:   &Window{9 * time.Hour, 12 * time.Hour, "UTC"},
+			}},
+			wait: 21 * time.Hour,
 		},
 	}
 	for _, test := range cases {
+		clock := NewFakeClock(start)
+		test.state.Use(clock, nil)
 		timer := test.state.Schedule()
 		if timer == nil {
-			t.Errorf("timer was nil")
+			t.Fatalf("timer was nil")
+		}
+		clock.Advance(test.wait - time.Second)
+		select {
+		case <-timer.Chan():
+			t.Errorf("timer fired early for %v", test.state.Voting)
+		default:
+		}
+		clock.Advance(time.Second)
+		select {
+		case <-timer.Chan():
+		default:
+			t.Errorf("timer did not fire for %v", test.state.Voting)
 		}
 	}
 }
 
+func TestStateScheduleReset(t *testing.T) {
+	clock := NewFakeClock(time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC))
+	state := &State{Voting: Voting{Duration: time.Minute}}
+	state.Use(clock, nil)
+	timer := state.Schedule()
+	clock.Advance(30 * time.Second)
+	state.Schedule()
+	clock.Advance(30 * time.Second)
+	select {
+	case <-timer.Chan():
+		t.Errorf("timer fired before reset duration")
+	default:
+	}
+	clock.Advance(30 * time.Second)
+	select {
+	case <-timer.Chan():
+	default:
+		t.Errorf("timer did not fire after reset duration")
+	}
+}
+
 func TestStateRandom(t *testing.T) {
 	cases := []struct {
 		state  *State
@@ -457,6 +543,63 @@ func TestStateRandom(t *testing.T) {
 	}
 }
 
+func TestStateRandomSeeded(t *testing.T) {
+	votes := []*Move{
+		{Coords: Coords{0, 0}},
+		{Coords: Coords{1, 1}},
+		{Coords: Coords{2, 2}},
+		{Pass: true},
+	}
+	pick := func(seed int64) []*Move {
+		state := &State{Votes: votes}
+		state.Use(nil, NewRNG(seed))
+		picks := []*Move{}
+		for i := 0; i < 10; i++ {
+			move, err := state.Random()
+			if err != nil {
+				t.Fatalf("unexpected error %s", err.Error())
+			}
+			picks = append(picks, move)
+		}
+		return picks
+	}
+	first, second := pick(42), pick(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed picked %v then %v", first, second)
+	}
+}
+
+func TestStateRandomPerGame(t *testing.T) {
+	votes := []*Move{}
+	for i := 0; i < 19; i++ {
+		votes = append(votes, &Move{Coords: Coords{i, i}})
+	}
+	// two games share a seed, and one of them draws more often than the other
+	// in between, as if its channel were busier
+	rng := NewRNG(42)
+	busy := &State{Votes: votes, History: History{NewBoard(19)}}
+	quiet := &State{Votes: votes, History: History{NewBoard(19)}}
+	busy.Use(nil, rng)
+	quiet.Use(nil, rng)
+	first, err := quiet.Random()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := busy.Random(); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	second, err := quiet.Random()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if first != second {
+		t.Errorf("expected the same position to pick %v but got %v", first,
+			second)
+	}
+}
+
 func TestStateEmpty(t *testing.T) {
 	cases := []struct {
 		state  *State
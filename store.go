@@ -4,100 +4,132 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"sort"
+	"fmt"
+	"strings"
 	"time"
 )
 
-// StateList is a list of states in some order
-type StateList []*State
-
-// Len is part of sort.Interface
-func (l StateList) Len() int {
-	return len(l)
-}
-
-// Swap is part of sort.Interface
-func (l StateList) Swap(i, j int) {
-	l[i], l[j] = l[j], l[i]
+// ConflictError is returned when saving a game that was changed by someone
+// else since it was loaded.
+type ConflictError struct {
+	ID int64
 }
 
-// Less is part of sort.Interface
-func (l StateList) Less(i, j int) bool {
-	return l[i].UpdatedAt.After(l[j].UpdatedAt)
+// Error implements the error interface
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf(
+		"game %d was changed by someone else, please try again", e.ID,
+	)
 }
 
-// StateStore stores a map of game IDs to corresponding games states in SQLite
+// StateStore stores a map of game IDs to corresponding games states in an
+// SQL database
 type StateStore struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Dialect Dialect
+	Clock   Clock
+	RNG     RNG
 }
 
 // NewGameStore creates a game store connected to an SQLite database for
 //  games.
 func NewGameStore(db *sql.DB) *StateStore {
-	return &StateStore{
-		DB: db,
-	}
+	return NewDialectStore(db, SQLite)
 }
 
-// Load persistent storage
-func (s *StateStore) Load() error {
-	stmt := `
-	CREATE TABLE IF NOT EXISTS
-	games
-	(
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		blob BLOB NOT NULL
-	)
-	`
-	_, err := s.DB.Exec(stmt)
-	return err
+// NewDialectStore creates a game store connected to a database that speaks
+// the given dialect
+func NewDialectStore(db *sql.DB, d Dialect) *StateStore {
+	return &StateStore{
+		DB:      db,
+		Dialect: d,
+		Clock:   SystemClock{},
+	}
 }
 
-// New creates a new Game and add it to the store
-func (s *StateStore) New(bp Blueprint) (*Session, error) {
-	game := &State{
-		History:   History([]Board{New19by19Board()}),
-		Next:      BlackStone,
-		Players:   Players(bp.Players),
-		Voting:    Voting(bp.Voting),
-		Captures:  Captures{0, 0},
-		Passes:    Passes{},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+// OpenStore opens a game store using a database/sql driver name, either
+// sqlite3 or postgres, and a data source name. The memory driver keeps games
+// in memory, snapshotting them to the file named by the source if given.
+func OpenStore(driver, source string) (Store, error) {
+	if driver == "memory" {
+		return NewMemoryStore(source), nil
 	}
-	blob, err := json.Marshal(game)
+	d, err := DialectFor(driver)
 	if err != nil {
 		return nil, err
 	}
-	stmt, err := s.DB.Prepare(`
-		INSERT INTO games (blob) VALUES(?)
-	`)
+	db, err := sql.Open(driver, source)
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
-	result, err := stmt.Exec(blob)
+	return NewDialectStore(db, d), nil
+}
+
+// Use a clock for timestamps and vote timers, and a random source for
+// picking votes
+func (s *StateStore) Use(c Clock, r RNG) {
+	s.Clock = c
+	s.RNG = r
+}
+
+// Load persistent storage, upgrading the schema if needed
+func (s *StateStore) Load() error {
+	return Migrate(s.DB, s.Dialect, s.Dialect.Migrations())
+}
+
+// New creates a new Game and add it to the store
+func (s *StateStore) New(bp Blueprint) (*Session, error) {
+	game := newState(bp, s.now())
+	game.Use(s.Clock, s.RNG)
+	blob, err := json.Marshal(game)
 	if err != nil {
 		return nil, err
 	}
-	id, err := result.LastInsertId()
+	meta := game.Metadata()
+	err = s.transact(func(tx *sql.Tx) error {
+		id, err := s.Dialect.Insert(tx, `
+			INSERT INTO games
+			(
+				blob, finished, archived, created_at, updated_at,
+				channel, players, waiting
+			)
+			VALUES(?, ?, ?, ?, ?, ?, ?, ?)`,
+			blob,
+			meta.Finished, meta.Archived,
+			timestamp(meta.CreatedAt), timestamp(meta.UpdatedAt),
+			meta.Channel, meta.PlayerList(), meta.WaitingList(),
+		)
+		if err != nil {
+			return err
+		}
+		game.id = id
+		return s.appendEvents(tx, []Event{{
+			Game:      id,
+			Kind:      CreatedEvent,
+			Player:    bp.Creator,
+			At:        game.CreatedAt,
+			Blueprint: &bp,
+		}})
+	})
 	if err != nil {
 		return nil, err
 	}
-	game.id = id
 	return NewSession(game, game, game, game), nil
 }
 
 // Get a game by id
 func (s *StateStore) Get(id int64) (*Session, error) {
-	stmt, err := s.DB.Prepare(`SELECT blob FROM games WHERE id = ?`)
+	stmt, err := s.DB.Prepare(
+		s.Dialect.Rebind(`SELECT blob, version FROM games WHERE id = ?`),
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer stmt.Close()
 	row := stmt.QueryRow(id)
 	var blob []byte
-	err = row.Scan(&blob)
+	var version int64
+	err = row.Scan(&blob, &version)
 	if err != nil {
 		return nil, err
 	}
@@ -107,12 +139,19 @@ func (s *StateStore) Get(id int64) (*Session, error) {
 		return nil, err
 	}
 	game.id = id
+	game.version = version
+	game.Use(s.Clock, s.RNG)
 	return NewSession(game, game, game, game), nil
 }
 
 // Last returns the last state played
 func (s *StateStore) Last() (*Session, error) {
-	sessions, err := s.List(false)
+	sessions, err := s.query(`
+		SELECT id, blob, version FROM games
+		WHERE NOT finished AND NOT archived
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`)
 	if err != nil {
 		return nil, err
 	}
@@ -122,50 +161,325 @@ func (s *StateStore) Last() (*Session, error) {
 	return sessions[0], nil
 }
 
+// LastFinished returns the finished game started last
+func (s *StateStore) LastFinished() (*Session, error) {
+	sessions, err := s.query(`
+		SELECT id, blob, version FROM games
+		WHERE finished AND NOT archived
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, errors.New("no finished games")
+	}
+	return sessions[0], nil
+}
+
 // Save a game to persistent storage
 func (s *StateStore) Save(storable Storable) error {
-	storable.(*State).UpdatedAt = time.Now()
 	blob, err := storable.Save()
 	if err != nil {
 		return err
 	}
-	stmt, err := s.DB.Prepare(`UPDATE games SET blob = ? WHERE id = ?`)
+	meta := storable.Metadata()
+	events := storable.TakeEvents()
+	err = s.transact(func(tx *sql.Tx) error {
+		result, err := tx.Exec(s.Dialect.Rebind(`
+			UPDATE games SET
+				blob = ?, version = version + 1,
+				finished = ?, archived = ?, created_at = ?, updated_at = ?,
+				channel = ?, players = ?, waiting = ?
+			WHERE id = ? AND version = ?
+		`),
+			blob,
+			meta.Finished, meta.Archived,
+			timestamp(meta.CreatedAt), timestamp(meta.UpdatedAt),
+			meta.Channel, meta.PlayerList(), meta.WaitingList(),
+			storable.ID(), storable.Version(),
+		)
+		if err != nil {
+			return err
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return &ConflictError{ID: storable.ID()}
+		}
+		return s.appendEvents(tx, events)
+	})
 	if err != nil {
+		putBack(storable, events)
 		return err
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec(blob, storable.ID())
-	return err
+	storable.SetVersion(storable.Version() + 1)
+	return nil
 }
 
-// List gets a list of the games in the store
-func (s *StateStore) List(all bool) ([]*Session, error) {
-	list, err := s.listAll()
+// putBack records events taken from a storable again after saving it failed,
+// so they are not lost and are saved along with it next time
+func putBack(storable Storable, events []Event) {
+	for _, e := range events {
+		storable.Record(e)
+	}
+}
+
+// Restore an archived game, replacing any game with the same id
+func (s *StateStore) Restore(a ArchivedGame) error {
+	game := &State{}
+	err := json.Unmarshal(a.Game, game)
+	if err != nil {
+		return err
+	}
+	// write the game in the current format
+	blob, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	meta := game.Metadata()
+	events := restoredEvents(a)
+	return s.transact(func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			s.Dialect.Rebind(`DELETE FROM events WHERE game_id = ?`), a.ID,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(s.Dialect.Rebind(`DELETE FROM games WHERE id = ?`), a.ID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(s.Dialect.Rebind(`
+			INSERT INTO games
+			(
+				id, blob, version, finished, archived,
+				created_at, updated_at, channel, players, waiting
+			)
+			VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`),
+			a.ID, blob, a.Version,
+			meta.Finished, meta.Archived,
+			timestamp(meta.CreatedAt), timestamp(meta.UpdatedAt),
+			meta.Channel, meta.PlayerList(), meta.WaitingList(),
+		)
+		if err != nil {
+			return err
+		}
+		err = s.appendEvents(tx, events)
+		if err != nil {
+			return err
+		}
+		return s.Dialect.Restored(tx)
+	})
+}
+
+// Events returns the last events of a game, oldest first, or all of them if
+// limit is not positive
+func (s *StateStore) Events(id int64, limit int) ([]Event, error) {
+	query := `SELECT blob FROM events WHERE game_id = ? ORDER BY id DESC`
+	args := []interface{}{id}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.DB.Query(s.Dialect.Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
-	sort.Sort(list)
-	output := []*Session{}
-	for _, state := range list {
-		if all || !state.Finished() {
-			sess := NewSession(state, state, state, state)
-			output = append(output, sess)
+	defer rows.Close()
+	events := []Event{}
+	for rows.Next() {
+		var blob []byte
+		err = rows.Scan(&blob)
+		if err != nil {
+			return nil, err
+		}
+		e := Event{}
+		err = json.Unmarshal(blob, &e)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	// newest first makes the limit keep the latest ones, so flip them back
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+// appendEvents adds events to the log of their game
+func (s *StateStore) appendEvents(tx *sql.Tx, events []Event) error {
+	for _, e := range events {
+		blob, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(s.Dialect.Rebind(`
+			INSERT INTO events (game_id, kind, player, blob, created_at)
+			VALUES(?, ?, ?, ?, ?)
+		`), e.Game, string(e.Kind), e.Player, blob, timestamp(e.At))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transact runs f in a transaction, committing if it succeeds and rolling
+// back if it fails
+func (s *StateStore) transact(f func(*sql.Tx) error) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	err = f(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// List gets a list of the games in the store that are not archived, most
+// recently updated first
+func (s *StateStore) List(all bool) ([]*Session, error) {
+	where := "WHERE NOT finished AND NOT archived"
+	if all {
+		where = "WHERE NOT archived"
+	}
+	return s.query(`
+		SELECT id, blob, version FROM games
+		` + where + `
+		ORDER BY updated_at DESC
+	`)
+}
+
+// Search for the games matching a filter, most recently updated first, and
+// count how many match in all
+func (s *StateStore) Search(f Filter) ([]*Session, int, error) {
+	conds := []string{"NOT archived"}
+	if f.Archived {
+		conds = []string{"archived"}
+	} else if !f.All {
+		conds = append(conds, "NOT finished")
+	}
+	args := []interface{}{}
+	if f.Player != "" {
+		conds = append(conds, `players LIKE ? ESCAPE '\'`)
+		args = append(args, likePlayer(f.Player))
+	}
+	if f.Waiting != "" {
+		conds = append(conds, `waiting LIKE ? ESCAPE '\'`)
+		args = append(args, likePlayer(f.Waiting))
+	}
+	where := "WHERE " + strings.Join(conds, " AND ")
+	var total int
+	err := s.DB.QueryRow(
+		s.Dialect.Rebind(`SELECT COUNT(*) FROM games `+where), args...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := `
+		SELECT id, blob, version FROM games
+		` + where + `
+		ORDER BY updated_at DESC, id DESC
+	`
+	if f.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, f.Limit, f.Offset)
+	}
+	sessions, err := s.query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+// likePlayer is a LIKE pattern matching a player in a list of players padded
+// with spaces, with the wildcards in the player escaped
+func likePlayer(player string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).
+		Replace(player)
+	return "% " + escaped + " %"
+}
+
+// ListArchived gets a list of the archived games in the store, most recently
+// updated first
+func (s *StateStore) ListArchived() ([]*Session, error) {
+	return s.query(`
+		SELECT id, blob, version FROM games
+		WHERE archived
+		ORDER BY updated_at DESC
+	`)
+}
+
+// Archive a game so it is no longer listed
+func (s *StateStore) Archive(id int64) error {
+	return archive(s, id)
+}
+
+// Delete a game and its events
+func (s *StateStore) Delete(id int64) error {
+	return s.transact(func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			s.Dialect.Rebind(`DELETE FROM events WHERE game_id = ?`), id,
+		)
+		if err != nil {
+			return err
+		}
+		result, err := tx.Exec(
+			s.Dialect.Rebind(`DELETE FROM games WHERE id = ?`), id,
+		)
+		if err != nil {
+			return err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return err
 		}
+		if deleted == 0 {
+			return fmt.Errorf("game %d does not exist", id)
+		}
+		return nil
+	})
+}
+
+// archive loads a game from a store, archives it and saves it again
+func archive(s Store, id int64) error {
+	sess, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	_, err = ArchivePipeline.Run(sess, "", nil)
+	if err != nil {
+		return err
 	}
-	return output, nil
+	return s.Save(sess.Storable)
 }
 
-func (s *StateStore) listAll() (StateList, error) {
-	list := StateList([]*State{})
-	rows, err := s.DB.Query("SELECT id, blob FROM games")
+// query games and load them into sessions
+func (s *StateStore) query(
+	query string, args ...interface{},
+) ([]*Session, error) {
+	rows, err := s.DB.Query(s.Dialect.Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	output := []*Session{}
 	for rows.Next() {
-		var id int64
+		var id, version int64
 		var blob []byte
-		err = rows.Scan(&id, &blob)
+		err = rows.Scan(&id, &blob, &version)
 		if err != nil {
 			return nil, err
 		}
@@ -175,9 +489,27 @@ func (s *StateStore) listAll() (StateList, error) {
 			return nil, err
 		}
 		game.id = id
-		list = append(list, game)
+		game.version = version
+		game.Use(s.Clock, s.RNG)
+		output = append(output, NewSession(game, game, game, game))
+	}
+	return output, rows.Err()
+}
+
+// timestamp converts a time to nanoseconds since the epoch for sorting in SQL.
+// The zero time is stored as 0.
+func timestamp(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func (s *StateStore) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
 	}
-	return StateList(list), nil
+	return s.Clock.Now()
 }
 
 // Close closes the store
@@ -2,13 +2,48 @@ package gobot_test
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/crestonbunch/gobot"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
 
+// postgresImage is the docker image the store tests start PostgreSQL from
+// when no database is configured
+const postgresImage = "postgres:10-alpine"
+
+// dialects the store tests run against
+var dialects = []Dialect{SQLite, Postgres}
+
+// dialectSQL picks the pattern a query must match in a dialect, so tests
+// check the placeholders each database gets
+func dialectSQL(d Dialect, sqlite, postgres string) string {
+	if d == Postgres {
+		return postgres
+	}
+	return sqlite
+}
+
+// blobContains matches a blob argument that contains a substring
+type blobContains string
+
+func (b blobContains) Match(v driver.Value) bool {
+	blob, ok := v.([]byte)
+	return ok && strings.Contains(string(blob), string(b))
+}
+
 func TestSQLiteLoad(t *testing.T) {
+	legacy := `{"history":[[[0,1],[2,0]]],"next":2}`
 	cases := []struct {
 		setup func() (*sql.DB, sqlmock.Sqlmock)
 	}{
@@ -18,8 +53,95 @@ func TestSQLiteLoad(t *testing.T) {
 				if err != nil {
 					t.Fatalf(err.Error())
 				}
-				mock.ExpectExec("CREATE TABLE IF NOT EXISTS.+").
+				mock.ExpectExec("CREATE TABLE IF NOT EXISTS.+schema_version.+").
 					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT COALESCE.+").
+					WillReturnRows(
+						sqlmock.NewRows([]string{"v"}).AddRow(len(Migrations)),
+					)
+				return db, mock
+			},
+		}, {
+			setup: func() (*sql.DB, sqlmock.Sqlmock) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				mock.ExpectExec("CREATE TABLE IF NOT EXISTS.+schema_version.+").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT COALESCE.+").
+					WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow(1))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT.+pragma_table_info.+").
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+				mock.ExpectExec("ALTER TABLE games ADD COLUMN version.+").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO schema_version.+").
+					WithArgs(2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id, blob FROM games").
+					WillReturnRows(
+						sqlmock.NewRows([]string{"id", "blob"}).AddRow(1, legacy),
+					)
+				mock.ExpectExec("UPDATE games SET blob.+").
+					WithArgs(blobContains(`"history":[".B/W."]`), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO schema_version.+").
+					WithArgs(3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				for i := 0; i < 8; i++ {
+					mock.ExpectExec("(ALTER TABLE games|CREATE INDEX).+").
+						WillReturnResult(sqlmock.NewResult(0, 0))
+				}
+				mock.ExpectQuery("SELECT id, blob FROM games").
+					WillReturnRows(
+						sqlmock.NewRows([]string{"id", "blob"}).AddRow(1, legacy),
+					)
+				mock.ExpectExec("UPDATE games SET.+finished.+").
+					WithArgs(false, 0, 0, "", "", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO schema_version.+").
+					WithArgs(4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectExec("CREATE TABLE IF NOT EXISTS.+events.+").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("CREATE INDEX events_game_id.+").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO schema_version.+").
+					WithArgs(5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectExec("ALTER TABLE games ADD COLUMN archived.+").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("CREATE INDEX games_archived_updated_at.+").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO schema_version.+").
+					WithArgs(6).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectExec("ALTER TABLE games ADD COLUMN waiting.+").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("CREATE INDEX games_finished_created_at.+").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT id, blob FROM games").
+					WillReturnRows(
+						sqlmock.NewRows([]string{"id", "blob"}).AddRow(1, legacy),
+					)
+				mock.ExpectExec(`UPDATE games SET waiting = \? WHERE id = \?`).
+					WithArgs("", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO schema_version.+").
+					WithArgs(7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
 				return db, mock
 			},
 		},
@@ -38,108 +160,317 @@ func TestSQLiteLoad(t *testing.T) {
 	}
 }
 
-func TestSQLiteNew(t *testing.T) {
+func TestPostgresLoad(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer db.Close()
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS.+schema_version.+").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COALESCE.+").
+		WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS.+games.+BIGSERIAL.+").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for i := 0; i < 3; i++ {
+		mock.ExpectExec("CREATE INDEX.+").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec(`INSERT INTO schema_version.+\$1`).
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS.+events.+BIGSERIAL.+").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX events_game_id.+").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_version.+\$1`).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE games ADD COLUMN archived BOOLEAN.+").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX games_archived_updated_at.+").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_version.+\$1`).
+		WithArgs(6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE games ADD COLUMN waiting TEXT.+").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX games_finished_created_at.+").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, blob FROM games").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "blob"}))
+	mock.ExpectExec(`INSERT INTO schema_version.+\$1`).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	store := NewDialectStore(db, Postgres)
+	err = store.Load()
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf(err.Error())
+	}
+}
+
+func TestStoreNew(t *testing.T) {
 	cases := []struct {
 		bp    Blueprint
-		setup func(Blueprint) (*sql.DB, sqlmock.Sqlmock)
+		setup func(Dialect, Blueprint) (*sql.DB, sqlmock.Sqlmock)
 	}{
 		{
-			setup: func(bp Blueprint) (*sql.DB, sqlmock.Sqlmock) {
+			bp: Blueprint{
+				Players: Players{Black: []string{"U2"}, White: []string{"U1"}},
+				Channel: "C1",
+				Creator: "U1",
+			},
+			setup: func(d Dialect, bp Blueprint) (*sql.DB, sqlmock.Sqlmock) {
 				db, mock, err := sqlmock.New()
 				if err != nil {
 					t.Fatalf(err.Error())
 				}
-				stmt := mock.ExpectPrepare("INSERT INTO.+")
-				stmt.ExpectExec().
-					WithArgs(sqlmock.AnyArg()).
+				args := []driver.Value{
+					sqlmock.AnyArg(), false, false, sqlmock.AnyArg(),
+					sqlmock.AnyArg(), bp.Channel, " U1 U2 ", " U2 ",
+				}
+				mock.ExpectBegin()
+				if d == Postgres {
+					mock.ExpectQuery(
+						`INSERT INTO games.+VALUES\(\$1, \$2, \$3, \$4, \$5, ` +
+							`\$6, \$7, \$8\) RETURNING id$`,
+					).
+						WithArgs(args...).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				} else {
+					mock.ExpectExec(
+						`INSERT INTO games.+VALUES\(\?, \?, \?, \?, \?, \?, ` +
+							`\?, \?\)$`,
+					).
+						WithArgs(args...).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+				}
+				mock.ExpectExec(dialectSQL(d,
+					`INSERT INTO events.+VALUES\(\?, \?, \?, \?, \?\)$`,
+					`INSERT INTO events.+VALUES\(\$1, \$2, \$3, \$4, \$5\)$`,
+				)).
+					WithArgs(
+						1, "created", bp.Creator,
+						blobContains(`"kind":"created"`), sqlmock.AnyArg(),
+					).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 				return db, mock
 			},
 		},
 	}
-	for _, test := range cases {
-		db, mock := test.setup(test.bp)
-		defer db.Close()
-		store := NewGameStore(db)
-		_, err := store.New(test.bp)
-		if err != nil {
-			t.Errorf(err.Error())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf(err.Error())
+	for _, d := range dialects {
+		for _, test := range cases {
+			db, mock := test.setup(d, test.bp)
+			defer db.Close()
+			store := NewDialectStore(db, d)
+			sess, err := store.New(test.bp)
+			if err != nil {
+				t.Errorf(err.Error())
+			} else if sess.Storable.ID() != 1 {
+				t.Errorf("expected game 1 but got %d", sess.Storable.ID())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf(err.Error())
+			}
 		}
 	}
 }
 
-func TestSQLiteGet(t *testing.T) {
+func TestStoreGet(t *testing.T) {
 	cases := []struct {
 		id    int64
-		setup func(int64) (*sql.DB, sqlmock.Sqlmock)
+		setup func(Dialect, int64) (*sql.DB, sqlmock.Sqlmock)
 	}{
 		{
 			id: 1,
-			setup: func(id int64) (*sql.DB, sqlmock.Sqlmock) {
+			setup: func(d Dialect, id int64) (*sql.DB, sqlmock.Sqlmock) {
 				db, mock, err := sqlmock.New()
 				if err != nil {
 					t.Fatalf(err.Error())
 				}
-				stmt := mock.ExpectPrepare("SELECT.+")
+				stmt := mock.ExpectPrepare(dialectSQL(d,
+					`SELECT blob, version FROM games WHERE id = \?$`,
+					`SELECT blob, version FROM games WHERE id = \$1$`,
+				))
 				stmt.ExpectQuery().WillReturnRows(
-					sqlmock.NewRows([]string{"blob"}).AddRow("{}"),
+					sqlmock.NewRows([]string{"blob", "version"}).
+						AddRow("{}", 3),
 				).WithArgs(id)
 				return db, mock
 			},
 		},
 	}
-	for _, test := range cases {
-		db, mock := test.setup(test.id)
-		defer db.Close()
-		store := NewGameStore(db)
-		_, err := store.Get(test.id)
-		if err != nil {
-			t.Errorf(err.Error())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf(err.Error())
+	for _, d := range dialects {
+		for _, test := range cases {
+			db, mock := test.setup(d, test.id)
+			defer db.Close()
+			store := NewDialectStore(db, d)
+			_, err := store.Get(test.id)
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf(err.Error())
+			}
 		}
 	}
 }
 
-func TestSQLiteSave(t *testing.T) {
+func TestStoreSave(t *testing.T) {
 	cases := []struct {
-		state *State
-		setup func(*State) (*sql.DB, sqlmock.Sqlmock)
+		events   []Event
+		setup    func(Dialect, *State) (*sql.DB, sqlmock.Sqlmock)
+		conflict bool
+		err      bool
+		version  int64
 	}{
 		{
-			state: &State{},
-			setup: func(state *State) (*sql.DB, sqlmock.Sqlmock) {
+			setup: func(d Dialect, state *State) (*sql.DB, sqlmock.Sqlmock) {
 				db, mock, err := sqlmock.New()
 				if err != nil {
 					t.Fatalf(err.Error())
 				}
-				stmt := mock.ExpectPrepare("UPDATE.+")
-				stmt.ExpectExec().
+				mock.ExpectBegin()
+				mock.ExpectExec(dialectSQL(d,
+					`UPDATE games SET .+ WHERE id = \? AND version = \?$`,
+					`UPDATE games SET .+ WHERE id = \$9 AND version = \$10$`,
+				)).
 					WillReturnResult(sqlmock.NewResult(0, 1)).
-					WithArgs(sqlmock.AnyArg(), state.ID())
+					WithArgs(
+						sqlmock.AnyArg(), false, false,
+						0, sqlmock.AnyArg(), "", "", "",
+						state.ID(), state.Version(),
+					)
+				mock.ExpectCommit()
+				return db, mock
+			},
+			version: 1,
+		}, {
+			events: []Event{
+				NewMoveEvent("U1", &Move{Coords: Coords{3, 3}}),
+				{Kind: VoteEvent, Player: "U2", Move: &Move{Pass: true}},
+			},
+			setup: func(d Dialect, state *State) (*sql.DB, sqlmock.Sqlmock) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				mock.ExpectBegin()
+				mock.ExpectExec(dialectSQL(d,
+					`UPDATE games SET .+ WHERE id = \? AND version = \?$`,
+					`UPDATE games SET .+ WHERE id = \$9 AND version = \$10$`,
+				)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(dialectSQL(d,
+					`INSERT INTO events.+VALUES\(\?, \?, \?, \?, \?\)$`,
+					`INSERT INTO events.+VALUES\(\$1, \$2, \$3, \$4, \$5\)$`,
+				)).
+					WithArgs(
+						state.ID(), "move", "U1",
+						blobContains(`"kind":"move"`), sqlmock.AnyArg(),
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(dialectSQL(d,
+					`INSERT INTO events.+VALUES\(\?, \?, \?, \?, \?\)$`,
+					`INSERT INTO events.+VALUES\(\$1, \$2, \$3, \$4, \$5\)$`,
+				)).
+					WithArgs(
+						state.ID(), "vote", "U2",
+						blobContains(`"kind":"vote"`), sqlmock.AnyArg(),
+					).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit()
+				return db, mock
+			},
+			version: 1,
+		}, {
+			setup: func(d Dialect, state *State) (*sql.DB, sqlmock.Sqlmock) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				mock.ExpectBegin()
+				mock.ExpectExec(dialectSQL(d,
+					`UPDATE games SET .+ WHERE id = \? AND version = \?$`,
+					`UPDATE games SET .+ WHERE id = \$9 AND version = \$10$`,
+				)).
+					WillReturnResult(sqlmock.NewResult(0, 0)).
+					WithArgs(
+						sqlmock.AnyArg(), false, false,
+						0, sqlmock.AnyArg(), "", "", "",
+						state.ID(), state.Version(),
+					)
+				mock.ExpectRollback()
+				return db, mock
+			},
+			conflict: true,
+			version:  0,
+		}, {
+			events: []Event{
+				NewMoveEvent("U1", &Move{Coords: Coords{3, 3}}),
+			},
+			setup: func(d Dialect, state *State) (*sql.DB, sqlmock.Sqlmock) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE games.+").
+					WillReturnError(errors.New("disk full"))
+				mock.ExpectRollback()
 				return db, mock
 			},
+			err:     true,
+			version: 0,
 		},
 	}
-	for _, test := range cases {
-		db, mock := test.setup(test.state)
-		defer db.Close()
-		store := NewGameStore(db)
-		err := store.Save(test.state)
-		if err != nil {
-			t.Errorf(err.Error())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf(err.Error())
+	for _, d := range dialects {
+		for _, test := range cases {
+			state := &State{}
+			for _, e := range test.events {
+				state.Record(e)
+			}
+			db, mock := test.setup(d, state)
+			defer db.Close()
+			store := NewDialectStore(db, d)
+			err := store.Save(state)
+			if _, ok := err.(*ConflictError); ok != test.conflict {
+				t.Errorf("expected conflict %t but got %v", test.conflict, err)
+			} else if (err != nil) != (test.conflict || test.err) {
+				t.Errorf("unexpected error %v", err)
+			}
+			// events that failed to save are kept for the next attempt
+			kept := state.TakeEvents()
+			if err == nil && len(kept) != 0 ||
+				err != nil && len(kept) != len(test.events) {
+				t.Errorf("expected to keep %v but kept %v", test.events, kept)
+			}
+			if state.Version() != test.version {
+				t.Errorf(
+					"expected version %d but got %d",
+					test.version, state.Version(),
+				)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf(err.Error())
+			}
 		}
 	}
 }
 
-func TestSQLiteList(t *testing.T) {
+func TestStoreList(t *testing.T) {
 	cases := []struct {
 		all   bool
 		setup func(bool) (*sql.DB, sqlmock.Sqlmock)
@@ -151,26 +482,483 @@ func TestSQLiteList(t *testing.T) {
 				if err != nil {
 					t.Fatalf(err.Error())
 				}
-				mock.ExpectQuery("SELECT.+").
-					WillReturnRows(
-						sqlmock.
-							NewRows([]string{"id", "blob"}).
-							AddRow(1, "{}"),
-					)
+				mock.ExpectQuery(
+					"SELECT.+WHERE NOT archived.+ORDER BY updated_at DESC",
+				).WillReturnRows(
+					sqlmock.
+						NewRows([]string{"id", "blob", "version"}).
+						AddRow(1, "{}", 0),
+				)
+				return db, mock
+			},
+		}, {
+			all: false,
+			setup: func(all bool) (*sql.DB, sqlmock.Sqlmock) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				mock.ExpectQuery(
+					"SELECT.+WHERE NOT finished AND NOT archived.+" +
+						"ORDER BY updated_at DESC",
+				).WillReturnRows(
+					sqlmock.
+						NewRows([]string{"id", "blob", "version"}).
+						AddRow(1, "{}", 0),
+				)
 				return db, mock
 			},
 		},
 	}
-	for _, test := range cases {
-		db, mock := test.setup(test.all)
+	for _, d := range dialects {
+		for _, test := range cases {
+			db, mock := test.setup(test.all)
+			defer db.Close()
+			store := NewDialectStore(db, d)
+			_, err := store.List(test.all)
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf(err.Error())
+			}
+		}
+	}
+}
+
+func TestStoreLast(t *testing.T) {
+	cases := []struct {
+		setup func() (*sql.DB, sqlmock.Sqlmock)
+		id    int64
+		err   bool
+	}{
+		{
+			setup: func() (*sql.DB, sqlmock.Sqlmock) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				mock.ExpectQuery(
+					"SELECT.+WHERE NOT finished.+ORDER BY updated_at DESC.+LIMIT 1",
+				).WillReturnRows(
+					sqlmock.
+						NewRows([]string{"id", "blob", "version"}).
+						AddRow(2, "{}", 0),
+				)
+				return db, mock
+			},
+			id: 2,
+		}, {
+			setup: func() (*sql.DB, sqlmock.Sqlmock) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				mock.ExpectQuery("SELECT.+LIMIT 1").WillReturnRows(
+					sqlmock.NewRows([]string{"id", "blob", "version"}),
+				)
+				return db, mock
+			},
+			err: true,
+		},
+	}
+	for _, d := range dialects {
+		for _, test := range cases {
+			db, mock := test.setup()
+			defer db.Close()
+			store := NewDialectStore(db, d)
+			sess, err := store.Last()
+			if (err != nil) != test.err {
+				t.Errorf("expected error %t but got %v", test.err, err)
+			} else if err == nil && sess.Storable.ID() != test.id {
+				t.Errorf("expected game %d but got %d", test.id, sess.Storable.ID())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf(err.Error())
+			}
+		}
+	}
+}
+
+func TestStoreLastFinished(t *testing.T) {
+	cases := []struct {
+		setup func() (*sql.DB, sqlmock.Sqlmock)
+		id    int64
+		err   bool
+	}{
+		{
+			setup: func() (*sql.DB, sqlmock.Sqlmock) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				mock.ExpectQuery(
+					"SELECT.+WHERE finished AND NOT archived.+" +
+						"ORDER BY created_at DESC, id DESC.+LIMIT 1",
+				).WillReturnRows(
+					sqlmock.
+						NewRows([]string{"id", "blob", "version"}).
+						AddRow(2, "{}", 0),
+				)
+				return db, mock
+			},
+			id: 2,
+		}, {
+			setup: func() (*sql.DB, sqlmock.Sqlmock) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				mock.ExpectQuery("SELECT.+LIMIT 1").WillReturnRows(
+					sqlmock.NewRows([]string{"id", "blob", "version"}),
+				)
+				return db, mock
+			},
+			err: true,
+		},
+	}
+	for _, d := range dialects {
+		for _, test := range cases {
+			db, mock := test.setup()
+			defer db.Close()
+			store := NewDialectStore(db, d)
+			sess, err := store.LastFinished()
+			if (err != nil) != test.err {
+				t.Errorf("expected error %t but got %v", test.err, err)
+			} else if err == nil && sess.Storable.ID() != test.id {
+				t.Errorf("expected game %d but got %d", test.id, sess.Storable.ID())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf(err.Error())
+			}
+		}
+	}
+}
+
+func TestStoreSearch(t *testing.T) {
+	for _, d := range dialects {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
 		defer db.Close()
-		store := NewGameStore(db)
-		_, err := store.List(test.all)
+		mock.ExpectQuery(dialectSQL(d,
+			`^SELECT COUNT\(\*\) FROM games WHERE NOT archived AND `+
+				`NOT finished AND players LIKE \? ESCAPE '\\' AND `+
+				`waiting LIKE \? ESCAPE '\\'$`,
+			`^SELECT COUNT\(\*\) FROM games WHERE NOT archived AND `+
+				`NOT finished AND players LIKE \$1 ESCAPE '\\' AND `+
+				`waiting LIKE \$2 ESCAPE '\\'$`,
+		)).
+			WithArgs("% U3 %", "% U\\_1 %").
+			WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(12))
+		mock.ExpectQuery(dialectSQL(d,
+			`ORDER BY updated_at DESC, id DESC LIMIT \? OFFSET \?$`,
+			`waiting LIKE \$2 .+ LIMIT \$3 OFFSET \$4$`,
+		)).
+			WithArgs("% U3 %", "% U\\_1 %", 10, 10).
+			WillReturnRows(
+				sqlmock.
+					NewRows([]string{"id", "blob", "version"}).
+					AddRow(2, "{}", 0),
+			)
+		store := NewDialectStore(db, d)
+		list, total, err := store.Search(Filter{
+			Player: "U3", Waiting: "U_1", Offset: 10, Limit: 10,
+		})
 		if err != nil {
 			t.Errorf(err.Error())
+		} else if len(list) != 1 || total != 12 {
+			t.Errorf("expected 1 game of 12 but got %d of %d", len(list), total)
 		}
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf(err.Error())
 		}
 	}
 }
+
+// TestStoreDatabase plays a game against real databases: an in-memory SQLite
+// database, and a throwaway PostgreSQL database from postgresSource.
+func TestStoreDatabase(t *testing.T) {
+	postgres, stop := postgresSource(t)
+	defer stop()
+	sources := []struct {
+		driver string
+		source string
+	}{
+		{"sqlite3", ":memory:"},
+		{"postgres", postgres},
+	}
+	for _, src := range sources {
+		if src.source == "" {
+			t.Logf("skipping %s, no database configured", src.driver)
+			continue
+		}
+		store, err := OpenStore(src.driver, src.source)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		db := store.(*StateStore).DB
+		// every connection to :memory: is a new database
+		db.SetMaxOpenConns(1)
+		if src.driver == "postgres" {
+			_, err = db.Exec(
+				`DROP TABLE IF EXISTS games, events, schema_version`,
+			)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+		}
+		testStore(t, src.driver, store)
+		store.Close()
+	}
+}
+
+// postgresSource finds a throwaway PostgreSQL database to test against: the
+// connection string in GOBOT_TEST_POSTGRES, or a docker container started
+// for the test and stopped with the returned function. The source is empty
+// if there is neither, which fails the test on continuous integration, where
+// CI is set, so the PostgreSQL queries are never left untested there.
+func postgresSource(t *testing.T) (string, func()) {
+	if source := os.Getenv("GOBOT_TEST_POSTGRES"); source != "" {
+		return source, func() {}
+	}
+	fail := func(reason string) (string, func()) {
+		if os.Getenv("CI") != "" {
+			t.Fatalf("no PostgreSQL to test against: %s", reason)
+		}
+		return "", func() {}
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fail("set GOBOT_TEST_POSTGRES or install docker")
+	}
+	out, err := exec.Command(
+		"docker", "run", "--detach", "--rm", "--publish", "127.0.0.1::5432",
+		"--env", "POSTGRES_PASSWORD=gobot", postgresImage,
+	).Output()
+	if err != nil {
+		return fail("could not start " + postgresImage + ": " + err.Error())
+	}
+	id := strings.TrimSpace(string(out))
+	stop := func() { exec.Command("docker", "stop", id).Run() }
+	out, err = exec.Command("docker", "port", id, "5432").Output()
+	if err != nil {
+		stop()
+		return fail("could not find the port of " + id + ": " + err.Error())
+	}
+	address := strings.TrimSpace(strings.Split(string(out), "\n")[0])
+	source := "postgres://postgres:gobot@" + address +
+		"/postgres?sslmode=disable"
+	db, err := sql.Open("postgres", source)
+	if err != nil {
+		stop()
+		return fail(err.Error())
+	}
+	defer db.Close()
+	// the server takes a moment to accept connections
+	for start := time.Now(); ; time.Sleep(500 * time.Millisecond) {
+		err = db.Ping()
+		if err == nil {
+			return source, stop
+		}
+		if time.Since(start) > 30*time.Second {
+			stop()
+			return fail("PostgreSQL did not start: " + err.Error())
+		}
+	}
+}
+
+// testStore plays a game against a store, which must be empty
+func testStore(t *testing.T, driver string, store Store) {
+	err := store.Load()
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	// loading twice leaves the schema alone
+	err = store.Load()
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	first, err := store.New(Blueprint{Channel: "C1"})
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	second, err := store.New(Blueprint{Channel: "C2"})
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if first.Storable.ID() == second.Storable.ID() {
+		t.Errorf("%s: games share id %d", driver, first.Storable.ID())
+	}
+	move := &Move{Coords: Coords{3, 3}}
+	err = first.Game.Move(move)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	first.Storable.Record(NewMoveEvent("U1", move))
+	err = store.Save(first.Storable)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	last, err := store.Last()
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if last.Storable.ID() != first.Storable.ID() {
+		t.Errorf(
+			"%s: expected last game %d but got %d",
+			driver, first.Storable.ID(), last.Storable.ID(),
+		)
+	}
+	got, err := store.Get(first.Storable.ID())
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if got.Storable.Version() != 1 || got.Playable.Channel() != "C1" {
+		t.Errorf(
+			"%s: got version %d in %s", driver,
+			got.Storable.Version(), got.Playable.Channel(),
+		)
+	}
+	events, err := store.Events(first.Storable.ID(), 0)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	replayed, err := Replay(events)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if !reflect.DeepEqual(replayed.History, got.Game.(*State).History) {
+		t.Errorf("%s: replaying %v made a different game", driver, events)
+	}
+	events, err = store.Events(first.Storable.ID(), 1)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if len(events) != 1 || events[0].Kind != MoveEvent {
+		t.Errorf("%s: expected the last event to be the move: %v", driver, events)
+	}
+	// saving the stale copy conflicts
+	err = store.Save(second.Storable)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	second.Storable.SetVersion(0)
+	err = store.Save(second.Storable)
+	if _, ok := err.(*ConflictError); !ok {
+		t.Errorf("%s: expected conflict but got %v", driver, err)
+	}
+	list, err := store.List(false)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if len(list) != 2 {
+		t.Errorf("%s: expected 2 games but got %d", driver, len(list))
+	}
+	// restoring keeps the id and version, and new games come after it
+	blob, err := json.Marshal(got.Storable)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	err = store.Restore(ArchivedGame{
+		ID: 10, Version: 3, Game: blob, Events: events,
+	})
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	restored, err := store.Get(10)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if restored.Storable.Version() != 3 {
+		t.Errorf(
+			"%s: expected version 3 but got %d",
+			driver, restored.Storable.Version(),
+		)
+	}
+	next, err := store.New(Blueprint{})
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if next.Storable.ID() != 11 {
+		t.Errorf("%s: expected game 11 but got %d", driver, next.Storable.ID())
+	}
+	// archived games are only listed when asked for
+	err = store.Archive(10)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if err = store.Archive(10); err == nil {
+		t.Errorf("%s: expected archiving twice to fail", driver)
+	}
+	list, err = store.List(true)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	for _, sess := range list {
+		if sess.Storable.ID() == 10 {
+			t.Errorf("%s: listed archived game 10", driver)
+		}
+	}
+	list, err = store.ListArchived()
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if len(list) != 1 || !list[0].Game.Archived() {
+		t.Errorf("%s: expected archived game 10 but got %v", driver, list)
+	}
+	// deleting removes the game and its events
+	err = store.Delete(10)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if _, err = store.Get(10); err == nil {
+		t.Errorf("%s: expected deleted game 10 to be gone", driver)
+	}
+	events, err = store.Events(10, 0)
+	if err != nil {
+		t.Fatalf("%s: %s", driver, err.Error())
+	}
+	if len(events) != 0 {
+		t.Errorf("%s: expected no events but got %v", driver, events)
+	}
+	if err = store.Delete(10); err == nil {
+		t.Errorf("%s: expected deleting twice to fail", driver)
+	}
+	// searches filter and page games in the store
+	for _, p := range [][]string{{"U11", "U2"}, {"U2", "U11"}, {"U3", "U2"}} {
+		_, err = store.New(Blueprint{
+			Players: Players{Black: p[:1], White: p[1:]},
+		})
+		if err != nil {
+			t.Fatalf("%s: %s", driver, err.Error())
+		}
+	}
+	searches := []struct {
+		filter Filter
+		ids    []int64
+		total  int
+	}{
+		{Filter{Player: "U11", Limit: 1}, []int64{13}, 2},
+		{Filter{Player: "U11", Limit: 1, Offset: 1}, []int64{12}, 2},
+		{Filter{Waiting: "U2"}, []int64{13}, 1},
+		// wildcards in players are matched as they are
+		{Filter{Player: "U_1"}, []int64{}, 0},
+	}
+	for _, search := range searches {
+		found, total, err := store.Search(search.filter)
+		if err != nil {
+			t.Fatalf("%s: %s", driver, err.Error())
+		}
+		ids := []int64{}
+		for _, sess := range found {
+			ids = append(ids, sess.Storable.ID())
+		}
+		if !reflect.DeepEqual(ids, search.ids) || total != search.total {
+			t.Errorf(
+				"%s: %+v expected %v of %d but got %v of %d", driver,
+				search.filter, search.ids, search.total, ids, total,
+			)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package gobot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ListPageSize is how many games the list command shows at once
+const ListPageSize = 10
+
+// Summary describes a game in one line of the game list
+type Summary struct {
+	ID       int64
+	Players  Players
+	Moves    int
+	Next     Stone
+	Captures Captures
+	Finished bool
+	// Result of a finished game, e.g. B+3.5 or W+R
+	Result string
+	// Dead are the stones taken off as dead for the result
+	Dead []Coords
+	// Idle is how long ago the game was last changed
+	Idle time.Duration
+}
+
+// String implements the stringer interface
+func (s Summary) String() string {
+	return s.Describe(Mentions{})
+}
+
+// Describe the game, naming players with a directory
+func (s Summary) Describe(d Directory) string {
+	parts := []string{}
+	if s.Players.Anyone {
+		parts = append(parts, "anyone", "vote")
+	} else {
+		parts = append(parts, fmt.Sprintf(
+			"%s vs %s", playerNames(d, s.Players.Black),
+			playerNames(d, s.Players.White),
+		), "two-player")
+	}
+	parts = append(parts, plural(s.Moves, "move"))
+	if s.Finished && s.Result != "" {
+		parts = append(parts, "finished "+s.Result)
+	} else if s.Finished {
+		parts = append(parts, "finished")
+	} else {
+		parts = append(parts, colorName(s.Next)+" to play")
+	}
+	parts = append(parts, fmt.Sprintf(
+		"captures B %d W %d", s.Captures.Black, s.Captures.White,
+	))
+	parts = append(parts, "active "+ago(s.Idle))
+	return fmt.Sprintf("%d: %s", s.ID, strings.Join(parts, ", "))
+}
+
+// playerNames joins the names of the players of one color
+func playerNames(d Directory, players []string) string {
+	if len(players) == 0 {
+		return "nobody"
+	}
+	return strings.Join(names(d, players), ", ")
+}
+
+func colorName(s Stone) string {
+	if s == WhiteStone {
+		return "white"
+	}
+	return "black"
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// ago describes a duration in the past roughly, e.g. 5m ago
+func ago(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
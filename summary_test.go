@@ -0,0 +1,189 @@
+package gobot_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestSummaryString(t *testing.T) {
+	cases := []struct {
+		summary  Summary
+		expected string
+	}{
+		{
+			summary: Summary{
+				ID:      3,
+				Players: Players{Anyone: true},
+				Moves:   1,
+				Next:    WhiteStone,
+				Idle:    30 * time.Second,
+			},
+			expected: "3: anyone, vote, 1 move, white to play, " +
+				"captures B 0 W 0, active just now",
+		}, {
+			summary: Summary{
+				ID:       14,
+				Players:  Players{Black: []string{"U1"}, White: []string{"U2"}},
+				Moves:    23,
+				Next:     BlackStone,
+				Captures: Captures{Black: 2, White: 1},
+				Idle:     90 * time.Minute,
+			},
+			expected: "14: <@U1> vs <@U2>, two-player, 23 moves, " +
+				"black to play, captures B 2 W 1, active 1h ago",
+		}, {
+			summary: Summary{
+				ID:       15,
+				Players:  Players{Black: []string{"U1"}, White: []string{"U1"}},
+				Moves:    120,
+				Finished: true,
+				Idle:     50 * time.Hour,
+			},
+			expected: "15: <@U1> vs <@U1>, two-player, 120 moves, " +
+				"finished, captures B 0 W 0, active 2d ago",
+		}, {
+			summary: Summary{
+				ID:       16,
+				Players:  Players{Black: []string{"U1"}, White: []string{"U2"}},
+				Moves:    80,
+				Finished: true,
+				Result:   "B+R",
+				Idle:     time.Minute,
+			},
+			expected: "16: <@U1> vs <@U2>, two-player, 80 moves, " +
+				"finished B+R, captures B 0 W 0, active 1m ago",
+		},
+	}
+	for _, test := range cases {
+		if actual := test.summary.String(); actual != test.expected {
+			t.Errorf("expected %q but got %q", test.expected, actual)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	duel := Metadata{Players: []string{"U1", "U2"}, Waiting: []string{"U1"}}
+	finished := Metadata{Players: []string{"U1", "U2"}, Finished: true}
+	archived := Metadata{Players: []string{"U1", "U2"}, Archived: true}
+	cases := []struct {
+		filter Filter
+		meta   Metadata
+		expect bool
+	}{
+		{Filter{}, duel, true},
+		{Filter{}, finished, false},
+		{Filter{All: true}, finished, true},
+		{Filter{All: true}, archived, false},
+		{Filter{Archived: true}, archived, true},
+		{Filter{Archived: true}, duel, false},
+		{Filter{Player: "U2"}, duel, true},
+		{Filter{Player: "U3"}, duel, false},
+		{Filter{Waiting: "U1"}, duel, true},
+		{Filter{Waiting: "U2"}, duel, false},
+		{Filter{All: true, Waiting: "U1"}, finished, false},
+	}
+	for _, test := range cases {
+		if actual := test.filter.Match(test.meta); actual != test.expect {
+			t.Errorf(
+				"expected %v for %+v in %+v", test.expect, test.filter, test.meta,
+			)
+		}
+	}
+}
+
+func TestListCommand(t *testing.T) {
+	clock := NewFakeClock(time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryStore("")
+	store.Use(clock, NewRNG(1))
+	players := [][]string{{"U1", "U2"}, {"U2", "U3"}, {"U3", "U1"}}
+	for i := 0; i < 2*ListPageSize+2; i++ {
+		p := players[i%len(players)]
+		_, err := store.New(Blueprint{
+			Players: Players{Black: []string{p[0]}, White: []string{p[1]}},
+		})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		clock.Advance(time.Minute)
+	}
+	// finish the newest game, which U1 plays black
+	sess, err := store.Get(2*ListPageSize + 2)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	sess.Game.Move(&Move{Pass: true})
+	sess.Game.Move(&Move{Pass: true})
+	if err = store.Save(sess.Storable); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	cases := []struct {
+		command *ListCommand
+		rows    int
+		first   string
+		footer  string
+		err     bool
+	}{
+		{command: &ListCommand{}, rows: ListPageSize,
+			footer: "page 1 of 3, say list 2 for more"},
+		{command: &ListCommand{All: true}, rows: ListPageSize,
+			first:  "22: <@U1> vs <@U2>, two-player, 0 moves, finished W+6.5",
+			footer: "page 1 of 3, say list all 2 for more"},
+		{command: &ListCommand{All: true, Page: 3}, rows: 2,
+			footer: "page 3 of 3"},
+		{command: &ListCommand{All: true, Page: 4}, err: true},
+		{command: &ListCommand{Mine: true}, rows: ListPageSize,
+			first:  "21: <@U3> vs <@U1>",
+			footer: "page 1 of 2, say list mine 2 for more"},
+		{command: &ListCommand{Mine: true, Page: 2}, rows: 4,
+			footer: "page 2 of 2"},
+		{command: &ListCommand{Waiting: true}, rows: 7,
+			first: "19: <@U1> vs <@U2>"},
+		{command: &ListCommand{Player: "U3"}, rows: ListPageSize,
+			footer: "page 1 of 2, say list @U3 2 for more"},
+		{command: &ListCommand{Player: "U4"}, rows: 1,
+			first: "no games found"},
+	}
+	for _, test := range cases {
+		r, err := NewRequest(test.command, "U1", "C1", store)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		response, err := test.command.Execute(r)
+		if err != nil {
+			if !test.err {
+				t.Errorf("%+v: %s", test.command, err.Error())
+			}
+			continue
+		}
+		if test.err {
+			t.Errorf("%+v: expected an error", test.command)
+			continue
+		}
+		lines := strings.Split(response.Text, "\n")
+		if test.footer != "" {
+			if lines[len(lines)-1] != test.footer {
+				t.Errorf(
+					"%+v: expected footer %q in\n%s",
+					test.command, test.footer, response.Text,
+				)
+			}
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) != test.rows {
+			t.Errorf(
+				"%+v: expected %d rows in\n%s", test.command, test.rows,
+				response.Text,
+			)
+		}
+		if !strings.HasPrefix(lines[0], test.first) {
+			t.Errorf(
+				"%+v: expected the first row to start with %q in\n%s",
+				test.command, test.first, response.Text,
+			)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package gobot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Consequences describes what playing a stone at (x, y) does to the board:
+// the stones it captures, a ko it starts, self-atari and the groups it puts
+// in atari. Returns an error if the stone cannot be played there.
+func (b Board) Consequences(x, y int, stone Stone) ([]string, error) {
+	after, captures, err := b.Play(x, y, stone)
+	if err != nil {
+		return nil, err
+	}
+	consequences := []string{}
+	if captures > 0 {
+		consequences = append(consequences, fmt.Sprintf(
+			"captures %s", plural(captures, colorName(stone.Opponent())+" stone"),
+		))
+	}
+	// taking a single stone with a single stone that is then left with one
+	// liberty means the opponent could take straight back
+	ko := captures == 1 && after.Liberties(x, y) == 1 && b.alone(x, y, stone)
+	if ko {
+		consequences = append(consequences, "starts a ko")
+	} else if after.Liberties(x, y) == 1 {
+		consequences = append(consequences, fmt.Sprintf(
+			"self-atari at %s", Coords{x, y}.String(),
+		))
+	}
+	// only report the groups the move put in atari. The player's other
+	// groups cannot lose liberties, since stones played next to them join
+	// them.
+	played := after.group(x, y)
+	before := map[atari]bool{}
+	for _, g := range b.ataris() {
+		before[g] = true
+	}
+	for _, g := range after.ataris() {
+		if played[g.Coords] || before[g] {
+			continue
+		}
+		consequences = append(consequences, fmt.Sprintf(
+			"%s at %s in atari",
+			plural(g.Stones, colorName(g.Color)+" stone"), g.Coords.String(),
+		))
+	}
+	return consequences, nil
+}
+
+// alone checks that none of the neighbors of a point are stones of a color
+func (b Board) alone(x, y int, stone Stone) bool {
+	for _, n := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+		if b.Get(n[0], n[1]) == stone {
+			return false
+		}
+	}
+	return true
+}
+
+// group finds the points of the stone at (x, y) and its connected stones
+func (b Board) group(x, y int) map[Coords]bool {
+	rest, _ := b.Capture(x, y)
+	points := map[Coords]bool{}
+	for gy := range b {
+		for gx := range b[gy] {
+			if b.Get(gx, gy) != rest.Get(gx, gy) {
+				points[Coords{gx, gy}] = true
+			}
+		}
+	}
+	return points
+}
+
+// atari is a group of stones with one liberty, found by its first stone
+type atari struct {
+	Coords Coords
+	Color  Stone
+	Stones int
+}
+
+// ataris finds every group on the board with one liberty
+func (b Board) ataris() []atari {
+	found := []atari{}
+	seen := map[Coords]bool{}
+	for y := range b {
+		for x := range b[y] {
+			stone := b.Get(x, y)
+			if stone == EmptyStone || seen[Coords{x, y}] {
+				continue
+			}
+			group := b.group(x, y)
+			for c := range group {
+				seen[c] = true
+			}
+			if b.Liberties(x, y) == 1 {
+				found = append(found, atari{Coords{x, y}, stone, len(group)})
+			}
+		}
+	}
+	return found
+}
+
+// describeMove describes a move and what it does to the board it is played
+// on
+func describeMove(before Board, m *Move, stone Stone) string {
+	return m.String() + describeConsequences(before, m, stone)
+}
+
+// describeConsequences lists what a move does to the board it is played on
+// after a comma, or nothing if it does nothing of note
+func describeConsequences(before Board, m *Move, stone Stone) string {
+	if m.Pass {
+		return ""
+	}
+	consequences, err := before.Consequences(m.Coords[0], m.Coords[1], stone)
+	if err != nil || len(consequences) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(consequences, ", ")
+}
@@ -0,0 +1,92 @@
+package gobot_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/crestonbunch/gobot"
+)
+
+func TestConsequences(t *testing.T) {
+	cases := []struct {
+		board        string
+		x, y         int
+		consequences []string
+		err          bool
+	}{
+		{"..../..../..../....", 1, 1, []string{}, false},
+		{
+			".B../BW../.B../....", 2, 1,
+			[]string{"captures 1 white stone"}, false,
+		},
+		{
+			".BW./BW.W/.BW./....", 2, 1,
+			[]string{
+				"captures 1 white stone",
+				"starts a ko",
+				"1 white stone at A3 in atari",
+			},
+			false,
+		},
+		{
+			"WW./B../...", 1, 1,
+			[]string{"2 white stones at A1 in atari"}, false,
+		},
+		{".W./W.W/...", 1, 1, []string{"self-atari at B2"}, false},
+		// groups already in atari are not news
+		{"WW./BB./...", 2, 1, []string{}, false},
+		{"BW./.../...", 2, 2, []string{}, false},
+		{"WW./BB./...", 0, 0, nil, true},
+	}
+	for _, test := range cases {
+		b := compactBoard(t, test.board)
+		consequences, err := b.Consequences(test.x, test.y, BlackStone)
+		if err != nil && !test.err {
+			t.Errorf("%s: unexpected error %s", test.board, err.Error())
+		} else if err == nil && test.err {
+			t.Errorf("%s: expected an error", test.board)
+		} else if !reflect.DeepEqual(consequences, test.consequences) {
+			t.Errorf("%s: expected %q but got %q", test.board,
+				test.consequences, consequences)
+		}
+	}
+}
+
+func TestServerMoveConsequences(t *testing.T) {
+	server := newTestServer(t, "start U1 U2")
+	defer server.Close()
+	cases := []struct {
+		input   string
+		player  string
+		details string
+	}{
+		{"move A2", "U1", "move at A2"},
+		{"move A1", "U2", "move at A1, self-atari at A1"},
+		{"move B1", "U1", "move at B1, captures 1 white stone"},
+	}
+	for _, c := range cases {
+		if err := server.Handle(c.input, c.player, "C1"); err != nil {
+			t.Fatalf("%s: %s", c.input, err.Error())
+		}
+		r := <-server.Replies
+		if r.Details != c.details {
+			t.Errorf("%s: expected %q but got %q", c.input, c.details, r.Details)
+		}
+	}
+}
+
+func TestServerEngineConsequences(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	server.Engine = &fakeEngine{moves: []*Move{{Coords: Coords{0, 0}}}}
+	for _, input := range []string{"start U1 engine", "move A2"} {
+		if err := server.Handle(input, "U1", "C1"); err != nil {
+			t.Fatalf("%s: %s", input, err.Error())
+		}
+		<-server.Replies
+	}
+	details := "engine played A1, self-atari at A1"
+	if r := <-server.Replies; r.Details != details {
+		t.Errorf("expected %q but got %q", details, r.Details)
+	}
+}